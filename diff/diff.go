@@ -0,0 +1,234 @@
+// Package diff compares two IPLD-git Tree nodes and, for modified blobs,
+// generates unified diffs, directly off IPLD storage.
+package diff
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	git "github.com/ipfs/go-ipld-git"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+// Kind identifies what kind of change a Change represents.
+type Kind int
+
+const (
+	Add Kind = iota
+	Delete
+	Modify
+	Rename
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Add:
+		return "add"
+	case Delete:
+		return "delete"
+	case Modify:
+		return "modify"
+	case Rename:
+		return "rename"
+	default:
+		return "unknown"
+	}
+}
+
+// TreeEntry is a single named entry resolved while walking a Tree: its
+// path relative to the tree root, the hash it points at, and the git file
+// mode string (e.g. "100644", "40000").
+type TreeEntry struct {
+	Path string
+	Hash cid.Cid
+	Mode string
+}
+
+// Change describes one difference found between two trees.
+type Change struct {
+	From, To TreeEntry
+	Kind     Kind
+}
+
+// Diff recursively compares the trees at fromRoot and toRoot and returns
+// every Add/Delete/Modify found, in sorted-name order. Rename detection is
+// not performed here; run DetectRenames over the result to pair up
+// Add/Delete changes that look like renames.
+func Diff(ctx context.Context, ls ipld.LinkSystem, fromRoot, toRoot cid.Cid) ([]Change, error) {
+	return diffTrees(ctx, ls, fromRoot, toRoot, "")
+}
+
+func diffTrees(ctx context.Context, ls ipld.LinkSystem, fromRoot, toRoot cid.Cid, prefix string) ([]Change, error) {
+	fromEntries, err := listTree(ctx, ls, fromRoot, prefix)
+	if err != nil {
+		return nil, err
+	}
+	toEntries, err := listTree(ctx, ls, toRoot, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []Change
+	i, j := 0, 0
+	for i < len(fromEntries) || j < len(toEntries) {
+		switch {
+		case i >= len(fromEntries):
+			changes = append(changes, Change{To: toEntries[j], Kind: Add})
+			j++
+		case j >= len(toEntries):
+			changes = append(changes, Change{From: fromEntries[i], Kind: Delete})
+			i++
+		default:
+			a, b := fromEntries[i], toEntries[j]
+			switch treeEntryCompare(a, b) {
+			case 0:
+				if a.Hash != b.Hash {
+					sub, err := diffIfBothTrees(ctx, ls, a, b)
+					if err != nil {
+						return nil, err
+					}
+					if sub != nil {
+						changes = append(changes, sub...)
+					} else {
+						changes = append(changes, Change{From: a, To: b, Kind: Modify})
+					}
+				}
+				i++
+				j++
+			case -1:
+				changes = append(changes, Change{From: a, Kind: Delete})
+				i++
+			default:
+				changes = append(changes, Change{To: b, Kind: Add})
+				j++
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// diffIfBothTrees recurses when both sides of a differing entry are
+// subtrees, returning nil (not an error) when either side is a blob so the
+// caller falls back to a flat Modify.
+func diffIfBothTrees(ctx context.Context, ls ipld.LinkSystem, a, b TreeEntry) ([]Change, error) {
+	if a.Mode != treeMode || b.Mode != treeMode {
+		return nil, nil
+	}
+	return diffTrees(ctx, ls, a.Hash, b.Hash, a.Path)
+}
+
+const treeMode = "40000"
+
+// listTree loads root and returns its immediate entries as TreeEntry,
+// already in the name-sorted order git trees are stored in (with the
+// trailing-slash quirk: a directory named "foo" sorts as if it were
+// "foo/", so e.g. "foo.txt" sorts before the directory "foo").
+func listTree(ctx context.Context, ls ipld.LinkSystem, root cid.Cid, prefix string) ([]TreeEntry, error) {
+	if !root.Defined() {
+		return nil, nil
+	}
+
+	nd, err := ls.Load(ipld.LinkContext{Ctx: ctx}, cidlink.Link{Cid: root}, git.Type.Tree)
+	if err != nil {
+		return nil, fmt.Errorf("loading tree %s: %w", root, err)
+	}
+
+	it := nd.ListIterator()
+	if it == nil {
+		return nil, fmt.Errorf("%s did not resolve to a tree", root)
+	}
+
+	var out []TreeEntry
+	for !it.Done() {
+		_, v, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+
+		nameNode, err := v.LookupByString("Name")
+		if err != nil {
+			return nil, err
+		}
+		name, err := nameNode.AsString()
+		if err != nil {
+			return nil, err
+		}
+
+		modeNode, err := v.LookupByString("Mode")
+		if err != nil {
+			return nil, err
+		}
+		mode, err := modeNode.AsString()
+		if err != nil {
+			return nil, err
+		}
+
+		hashNode, err := v.LookupByString("Hash")
+		if err != nil {
+			return nil, err
+		}
+		lnk, err := hashNode.AsLink()
+		if err != nil {
+			return nil, err
+		}
+		cl, ok := lnk.(cidlink.Link)
+		if !ok {
+			return nil, fmt.Errorf("unexpected link implementation for %q", name)
+		}
+
+		path := name
+		if prefix != "" {
+			path = prefix + "/" + name
+		}
+		out = append(out, TreeEntry{Path: path, Hash: cl.Cid, Mode: mode})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return gitTreeEntryLess(out[i], out[j])
+	})
+
+	return out, nil
+}
+
+// gitTreeEntryLess orders two entries the way git itself sorts a tree
+// object: byte-wise on the name, except a directory name is compared as if
+// it had a trailing "/" so e.g. "foo.c" sorts before the directory "foo".
+func gitTreeEntryLess(a, b TreeEntry) bool {
+	an, bn := baseName(a.Path), baseName(b.Path)
+	if a.Mode == treeMode {
+		an += "/"
+	}
+	if b.Mode == treeMode {
+		bn += "/"
+	}
+	return an < bn
+}
+
+func baseName(path string) string {
+	if i := strings.LastIndexByte(path, '/'); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+// treeEntryCompare orders two entries from different trees by the same
+// trailing-slash-aware rule gitTreeEntryLess uses, so the merge walk in
+// diffTrees steps through both sides in the order their trees are sorted in.
+// An entry whose type changed between blob and tree compares unequal under
+// this rule ("foo" against "foo/"), so it comes out as a Delete and an Add
+// rather than a Modify.
+func treeEntryCompare(a, b TreeEntry) int {
+	switch {
+	case gitTreeEntryLess(a, b):
+		return -1
+	case gitTreeEntryLess(b, a):
+		return 1
+	default:
+		return 0
+	}
+}
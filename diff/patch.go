@@ -0,0 +1,312 @@
+package diff
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	git "github.com/ipfs/go-ipld-git"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+// PatchOptions configures unified-diff generation.
+type PatchOptions struct {
+	// Context is the number of unchanged lines kept around each hunk.
+	// Defaults to 3, matching `diff -u`/`git diff`.
+	Context int
+}
+
+// Patch generates a unified diff for every Modify change in changes whose
+// entries are blobs, loading both sides through ls. Changes that aren't
+// Modify, or whose entries aren't blobs, are skipped.
+func Patch(ctx context.Context, ls ipld.LinkSystem, changes []Change, opts PatchOptions) (string, error) {
+	if opts.Context <= 0 {
+		opts.Context = 3
+	}
+
+	var out strings.Builder
+	for _, c := range changes {
+		if c.Kind != Modify || c.From.Mode == treeMode || c.To.Mode == treeMode ||
+			c.From.Mode == gitlinkMode || c.To.Mode == gitlinkMode {
+			continue
+		}
+		hunk, err := patchOne(ctx, ls, c, opts)
+		if err != nil {
+			return "", err
+		}
+		out.WriteString(hunk)
+	}
+	return out.String(), nil
+}
+
+// gitlinkMode is the mode of a submodule entry, which points at a commit
+// rather than a blob.
+const gitlinkMode = "160000"
+
+func patchOne(ctx context.Context, ls ipld.LinkSystem, c Change, opts PatchOptions) (string, error) {
+	fromLines, err := loadBlobLines(ctx, ls, c.From.Hash)
+	if err != nil {
+		return "", fmt.Errorf("loading %s: %w", c.From.Path, err)
+	}
+	toLines, err := loadBlobLines(ctx, ls, c.To.Hash)
+	if err != nil {
+		return "", fmt.Errorf("loading %s: %w", c.To.Path, err)
+	}
+
+	ops := myersDiff(fromLines, toLines)
+	hunks := buildHunks(ops, opts.Context)
+	if len(hunks) == 0 {
+		return "", nil
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- a/%s\n", c.From.Path)
+	fmt.Fprintf(&out, "+++ b/%s\n", c.To.Path)
+	for _, h := range hunks {
+		out.WriteString(formatHunk(h, fromLines, toLines))
+	}
+	return out.String(), nil
+}
+
+func loadBlobBytes(ctx context.Context, ls ipld.LinkSystem, id cid.Cid) ([]byte, error) {
+	nd, err := ls.Load(ipld.LinkContext{Ctx: ctx}, cidlink.Link{Cid: id}, git.Type.Blob)
+	if err != nil {
+		return nil, err
+	}
+	blob, ok := nd.(git.Blob)
+	if !ok {
+		return nil, fmt.Errorf("%s did not resolve to a blob", id)
+	}
+	return blob.AsBytes()
+}
+
+func loadBlobLines(ctx context.Context, ls ipld.LinkSystem, id cid.Cid) ([]string, error) {
+	b, err := loadBlobBytes(ctx, ls, id)
+	if err != nil {
+		return nil, err
+	}
+
+	text := string(b)
+	if text == "" {
+		return nil, nil
+	}
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines, nil
+}
+
+// opKind is one line-level edit produced by myersDiff.
+type opKind int
+
+const (
+	opEqual opKind = iota
+	opDelete
+	opInsert
+)
+
+type editOp struct {
+	kind   opKind
+	aIndex int // index into `a`, valid for opEqual/opDelete
+	bIndex int // index into `b`, valid for opEqual/opInsert
+}
+
+// myersDiff computes the shortest edit script turning a into b, using the
+// classic O(ND) Myers algorithm.
+func myersDiff(a, b []string) []editOp {
+	n, m := len(a), len(b)
+	max := n + m
+	if max == 0 {
+		return nil
+	}
+
+	offset := max
+	size := 2*max + 1
+	trace := make([][]int, 0, max+1)
+
+	v := make([]int, size)
+	found := -1
+	var x, y int
+
+outer:
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, size)
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y = x - k
+
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				found = d
+				break outer
+			}
+		}
+	}
+
+	if found < 0 {
+		found = max
+	}
+
+	return backtrack(trace, a, b, n, m, offset)
+}
+
+// backtrack replays the Myers trace from (n,m) back to (0,0) to recover the
+// edit script, then reverses it into forward order.
+func backtrack(trace [][]int, a, b []string, n, m, offset int) []editOp {
+	var ops []editOp
+	x, y := n, m
+
+	for d := len(trace) - 1; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			ops = append(ops, editOp{kind: opEqual, aIndex: x, bIndex: y})
+		}
+
+		if d > 0 {
+			if x == prevX {
+				y--
+				ops = append(ops, editOp{kind: opInsert, bIndex: y})
+			} else {
+				x--
+				ops = append(ops, editOp{kind: opDelete, aIndex: x})
+			}
+		}
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
+
+// hunk is a contiguous run of edits plus opts.Context lines of surrounding
+// equal content on each side.
+type hunk struct {
+	aStart, aLen int
+	bStart, bLen int
+	ops          []editOp
+}
+
+// buildHunks groups the flat edit script into unified-diff hunks: it finds
+// each maximal run of non-equal ops, merges runs separated by no more than
+// 2*context equal lines (so their surrounding context would otherwise
+// overlap), then pads each merged group with up to context equal lines on
+// either side.
+func buildHunks(ops []editOp, context int) []hunk {
+	var regions [][2]int // [start,end) index ranges of non-equal ops
+	i := 0
+	for i < len(ops) {
+		if ops[i].kind == opEqual {
+			i++
+			continue
+		}
+		start := i
+		for i < len(ops) && ops[i].kind != opEqual {
+			i++
+		}
+		regions = append(regions, [2]int{start, i})
+	}
+	if len(regions) == 0 {
+		return nil
+	}
+
+	merged := [][2]int{regions[0]}
+	for _, r := range regions[1:] {
+		last := &merged[len(merged)-1]
+		if r[0]-last[1] <= 2*context {
+			last[1] = r[1]
+		} else {
+			merged = append(merged, r)
+		}
+	}
+
+	hunks := make([]hunk, 0, len(merged))
+	for _, r := range merged {
+		start := r[0] - context
+		if start < 0 {
+			start = 0
+		}
+		end := r[1] + context
+		if end > len(ops) {
+			end = len(ops)
+		}
+		hunks = append(hunks, buildOneHunk(ops[start:end]))
+	}
+	return hunks
+}
+
+func buildOneHunk(ops []editOp) hunk {
+	h := hunk{ops: ops}
+	first := true
+	for _, op := range ops {
+		switch op.kind {
+		case opEqual:
+			if first {
+				h.aStart, h.bStart = op.aIndex, op.bIndex
+				first = false
+			}
+			h.aLen++
+			h.bLen++
+		case opDelete:
+			if first {
+				h.aStart = op.aIndex
+				first = false
+			}
+			h.aLen++
+		case opInsert:
+			if first {
+				h.bStart = op.bIndex
+				first = false
+			}
+			h.bLen++
+		}
+	}
+	return h
+}
+
+func formatHunk(h hunk, a, b []string) string {
+	var out strings.Builder
+	fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", h.aStart+1, h.aLen, h.bStart+1, h.bLen)
+	for _, op := range h.ops {
+		switch op.kind {
+		case opEqual:
+			out.WriteString(" ")
+			out.WriteString(a[op.aIndex])
+		case opDelete:
+			out.WriteString("-")
+			out.WriteString(a[op.aIndex])
+		case opInsert:
+			out.WriteString("+")
+			out.WriteString(b[op.bIndex])
+		}
+	}
+	return out.String()
+}
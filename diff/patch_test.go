@@ -0,0 +1,55 @@
+package diff
+
+import "testing"
+
+func TestMyersDiffAndHunks(t *testing.T) {
+	a := []string{"one\n", "two\n", "three\n", "four\n", "five\n"}
+	b := []string{"one\n", "TWO\n", "three\n", "four\n", "five\n"}
+
+	ops := myersDiff(a, b)
+
+	var got []string
+	for _, op := range ops {
+		switch op.kind {
+		case opEqual:
+			got = append(got, " "+a[op.aIndex])
+		case opDelete:
+			got = append(got, "-"+a[op.aIndex])
+		case opInsert:
+			got = append(got, "+"+b[op.bIndex])
+		}
+	}
+
+	want := []string{" one\n", "-two\n", "+TWO\n", " three\n", " four\n", " five\n"}
+	if len(got) != len(want) {
+		t.Fatalf("got %d ops, want %d: %v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("op %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	hunks := buildHunks(ops, 1)
+	if len(hunks) != 1 {
+		t.Fatalf("expected a single hunk, got %d", len(hunks))
+	}
+	out := formatHunk(hunks[0], a, b)
+	wantHunk := "@@ -1,3 +1,3 @@\n one\n-two\n+TWO\n three\n"
+	if out != wantHunk {
+		t.Fatalf("got hunk:\n%s\nwant:\n%s", out, wantHunk)
+	}
+}
+
+func TestShingleSimilarity(t *testing.T) {
+	a := map[uint64]struct{}{1: {}, 2: {}, 3: {}}
+	b := map[uint64]struct{}{2: {}, 3: {}, 4: {}}
+
+	// intersection 2, union 4 -> 0.5
+	if got := shingleSimilarity(a, b); got != 0.5 {
+		t.Fatalf("got %v, want 0.5", got)
+	}
+	if got := shingleSimilarity(a, map[uint64]struct{}{}); got != 0 {
+		t.Fatalf("got %v, want 0", got)
+	}
+}
@@ -0,0 +1,139 @@
+package diff
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime"
+)
+
+// RenameOptions configures DetectRenames.
+type RenameOptions struct {
+	// Threshold is the minimum fraction (0-1) of shared shingles for two
+	// entries to be considered a rename. Defaults to 0.5.
+	Threshold float64
+	// ShingleSize is the width, in bytes, of the rolling window hashed to
+	// build each blob's shingle set. Defaults to 64.
+	ShingleSize int
+}
+
+// DetectRenames is an optional pass over a Diff result that pairs up
+// Add/Delete changes whose blobs are similar enough to be the same file
+// moved or renamed, replacing both with a single Rename change.
+func DetectRenames(ctx context.Context, ls ipld.LinkSystem, changes []Change, opts RenameOptions) ([]Change, error) {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 0.5
+	}
+	if opts.ShingleSize <= 0 {
+		opts.ShingleSize = 64
+	}
+
+	var adds, deletes, rest []Change
+	for _, c := range changes {
+		switch c.Kind {
+		case Add:
+			adds = append(adds, c)
+		case Delete:
+			deletes = append(deletes, c)
+		default:
+			rest = append(rest, c)
+		}
+	}
+
+	addShingles := make([]map[uint64]struct{}, len(adds))
+	for i, c := range adds {
+		set, err := blobShingles(ctx, ls, c.To.Hash, opts.ShingleSize)
+		if err != nil {
+			return nil, err
+		}
+		addShingles[i] = set
+	}
+
+	usedAdds := make([]bool, len(adds))
+	var out []Change
+	for _, d := range deletes {
+		delSet, err := blobShingles(ctx, ls, d.From.Hash, opts.ShingleSize)
+		if err != nil {
+			return nil, err
+		}
+
+		bestIdx := -1
+		bestScore := 0.0
+		for i := range adds {
+			if usedAdds[i] {
+				continue
+			}
+			score := shingleSimilarity(delSet, addShingles[i])
+			if score > bestScore {
+				bestScore = score
+				bestIdx = i
+			}
+		}
+
+		if bestIdx >= 0 && bestScore >= opts.Threshold {
+			usedAdds[bestIdx] = true
+			out = append(out, Change{From: d.From, To: adds[bestIdx].To, Kind: Rename})
+		} else {
+			out = append(out, d)
+		}
+	}
+
+	for i, a := range adds {
+		if !usedAdds[i] {
+			out = append(out, a)
+		}
+	}
+
+	return append(out, rest...), nil
+}
+
+// blobShingles loads the blob at id and returns the set of FNV-1a hashes of
+// every size-byte window in it.
+func blobShingles(ctx context.Context, ls ipld.LinkSystem, id cid.Cid, size int) (map[uint64]struct{}, error) {
+	b, err := loadBlobBytes(ctx, ls, id)
+	if err != nil {
+		return nil, err
+	}
+
+	set := make(map[uint64]struct{})
+	if len(b) < size {
+		if len(b) > 0 {
+			set[fnv1a(b)] = struct{}{}
+		}
+		return set, nil
+	}
+	for i := 0; i+size <= len(b); i++ {
+		set[fnv1a(b[i:i+size])] = struct{}{}
+	}
+	return set, nil
+}
+
+func fnv1a(b []byte) uint64 {
+	var h uint64 = 14695981039346656037
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= 1099511628211
+	}
+	return h
+}
+
+func shingleSimilarity(a, b map[uint64]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	small, big := a, b
+	if len(big) < len(small) {
+		small, big = big, small
+	}
+	for h := range small {
+		if _, ok := big[h]; ok {
+			shared++
+		}
+	}
+	union := len(a) + len(b) - shared
+	if union == 0 {
+		return 0
+	}
+	return float64(shared) / float64(union)
+}
@@ -0,0 +1,163 @@
+package ipldgit
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// Progress reports how far a Loader has gotten through a batch of objects.
+type Progress struct {
+	Processed int
+	Total     int
+	Current   cid.Cid
+}
+
+// Loader fans loose-object ingestion out across Workers goroutines and
+// feeds the result into a LinkSystem, for repositories with enough objects
+// that ParseCompressedObject's one-at-a-time use in TestObjectParse becomes
+// the bottleneck.
+type Loader struct {
+	// LS is where parsed objects are stored.
+	LS ipld.LinkSystem
+	// Workers is how many goroutines decompress and parse objects
+	// concurrently. Defaults to runtime.NumCPU() when zero.
+	Workers int
+
+	// storeMu serializes calls into LS.Store, since most LinkSystem
+	// storage backends (including the common file/block-store ones) are
+	// not safe for concurrent writes.
+	storeMu sync.Mutex
+}
+
+var gitLinkPrototype = cidlink.LinkPrototype{
+	Prefix: cid.NewCidV1(cid.GitRaw, mh.Multihash{}).Prefix(),
+}
+
+// NewLoader returns a Loader storing into ls with runtime.NumCPU() workers.
+func NewLoader(ls ipld.LinkSystem) *Loader {
+	return &Loader{LS: ls, Workers: runtime.NumCPU()}
+}
+
+// LoadPaths decompresses, parses, and stores the loose object at each of
+// paths across l.Workers goroutines, reporting progress as it goes. The
+// progress channel is closed once every path has been processed (or ctx is
+// canceled); the error channel then receives exactly one value - nil on
+// success, or the first error encountered - and is also closed.
+func (l *Loader) LoadPaths(ctx context.Context, paths []string) (<-chan Progress, <-chan error) {
+	workers := l.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(paths) && len(paths) > 0 {
+		workers = len(paths)
+	}
+
+	progress := make(chan Progress, workers)
+	errc := make(chan error, 1)
+
+	if len(paths) == 0 || workers == 0 {
+		close(progress)
+		errc <- nil
+		close(errc)
+		return progress, errc
+	}
+
+	jobs := make(chan int)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		firstErr  error
+		processed int
+	)
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				select {
+				case <-ctx.Done():
+					return
+				default:
+				}
+
+				id, err := l.loadOne(ctx, paths[idx])
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = fmt.Errorf("%s: %w", paths[idx], err)
+				}
+				processed++
+				p := Progress{Processed: processed, Total: len(paths), Current: id}
+				mu.Unlock()
+
+				select {
+				case progress <- p:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+	dispatch:
+		for i := range paths {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				break dispatch
+			}
+		}
+		close(jobs)
+
+		wg.Wait()
+		close(progress)
+
+		mu.Lock()
+		err := firstErr
+		mu.Unlock()
+		if err == nil {
+			err = ctx.Err()
+		}
+		errc <- err
+		close(errc)
+	}()
+
+	return progress, errc
+}
+
+func (l *Loader) loadOne(ctx context.Context, path string) (cid.Cid, error) {
+	fi, err := os.Open(path)
+	if err != nil {
+		return cid.Undef, err
+	}
+	defer fi.Close()
+
+	nd, err := ParseCompressedObject(fi)
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	l.storeMu.Lock()
+	lnk, err := l.LS.Store(ipld.LinkContext{Ctx: ctx}, gitLinkPrototype, nd)
+	l.storeMu.Unlock()
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	cl, ok := lnk.(cidlink.Link)
+	if !ok {
+		return cid.Undef, fmt.Errorf("unexpected link implementation from LinkSystem.Store")
+	}
+	return cl.Cid, nil
+}
@@ -0,0 +1,194 @@
+package ipldgit
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// looseObjectPaths collects every loose object under .git/objects, the same
+// set TestObjectParse and BenchmarkRawData walk.
+func looseObjectPaths(tb testing.TB) []string {
+	tb.Helper()
+	var paths []string
+	err := filepath.Walk(".git/objects", func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		parts := strings.Split(path, string(filepath.Separator))
+		if dir := parts[len(parts)-2]; dir == "info" || dir == "pack" {
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return paths
+}
+
+func discardLinkSystem() ipld.LinkSystem {
+	sc := func(ipld.Link) error { return nil }
+	return ipld.LinkSystem{
+		StorageWriteOpener: func(ipld.LinkContext) (io.Writer, ipld.StoreCommitter, error) {
+			return io.Discard, sc, nil
+		},
+		EncoderChooser: func(ipld.LinkPrototype) (ipld.Encoder, error) {
+			return func(n ipld.Node, w io.Writer) error { return Encoder(n, w) }, nil
+		},
+	}
+}
+
+// archiveObjectPaths extracts every loose object under .git/objects/ from
+// the kernel-sized testdata.tar.gz fixture (the same archive
+// TestArchiveObjectParse reads) into a temporary directory and returns their
+// paths, so the ingestion benchmarks exercise a realistically sized repo
+// instead of whatever loose objects happen to exist in this checkout.
+func archiveObjectPaths(tb testing.TB) []string {
+	tb.Helper()
+
+	archive, err := os.Open("testdata.tar.gz")
+	if err != nil {
+		tb.Skip("no testdata.tar.gz fixture present")
+	}
+	defer archive.Close()
+
+	gz, err := gzip.NewReader(archive)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	dir := tb.TempDir()
+	var paths []string
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if header.Typeflag != tar.TypeReg || !strings.HasPrefix(header.Name, ".git/objects/") {
+			continue
+		}
+		parts := strings.Split(header.Name, "/")
+		if d := parts[2]; d == "info" || d == "pack" {
+			continue
+		}
+
+		dst := filepath.Join(dir, filepath.FromSlash(header.Name))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			tb.Fatal(err)
+		}
+		f, err := os.Create(dst)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			tb.Fatal(err)
+		}
+		f.Close()
+		paths = append(paths, dst)
+	}
+	if len(paths) == 0 {
+		tb.Skip("testdata.tar.gz contains no loose objects")
+	}
+	return paths
+}
+
+func BenchmarkLoaderSingleThreaded(b *testing.B) {
+	paths := archiveObjectPaths(b)
+	ls := discardLinkSystem()
+	l := &Loader{LS: ls, Workers: 1}
+
+	for i := 0; i < b.N; i++ {
+		progress, errc := l.LoadPaths(context.Background(), paths)
+		for range progress {
+		}
+		if err := <-errc; err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoaderParallel(b *testing.B) {
+	paths := archiveObjectPaths(b)
+	ls := discardLinkSystem()
+	l := NewLoader(ls)
+
+	for i := 0; i < b.N; i++ {
+		progress, errc := l.LoadPaths(context.Background(), paths)
+		for range progress {
+		}
+		if err := <-errc; err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestChunkAndParsePreservesSHA checks the invariant the request calls out
+// explicitly: splitting a blob into chunks via ChunkAndParse must not change
+// the git SHA-1 TestObjectParse verifies loose objects against. It parses
+// every loose object under .git/objects both the plain way and through
+// ChunkAndParse (with a small chunk size, so blobs in this repo's own
+// history actually get split into several chunks) and checks the two
+// resulting nodes hash to the same CID.
+func TestChunkAndParsePreservesSHA(t *testing.T) {
+	lb := cidlink.LinkBuilder{Prefix: cid.NewCidV1(cid.GitRaw, mh.Multihash{}).Prefix()}
+	storer := func(ipld.LinkContext) (io.Writer, ipld.StoreCommitter, error) {
+		return io.Discard, func(ipld.Link) error { return nil }, nil
+	}
+	ls := discardLinkSystem()
+
+	paths := looseObjectPaths(t)
+	if len(paths) == 0 {
+		t.Skip("no loose objects under .git/objects")
+	}
+
+	for _, path := range paths {
+		plain, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		nd, err := ParseCompressedObject(plain)
+		plain.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		wantLink, err := lb.Build(context.Background(), ipld.LinkContext{}, nd, storer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		chunked, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunkNd, _, err := ChunkAndParse(context.Background(), chunked, ls, 8)
+		chunked.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		gotLink, err := lb.Build(context.Background(), ipld.LinkContext{}, chunkNd, storer)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if wantLink.(cidlink.Link).Cid != gotLink.(cidlink.Link).Cid {
+			t.Fatalf("%s: chunked SHA %s != loose SHA %s", path, gotLink, wantLink)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package pack
+
+import "fmt"
+
+// applyDelta reconstructs an object's bytes from a base and a git delta
+// instruction stream: a varint source size, a varint target size, then a
+// sequence of copy (0x80 bit set) and insert (0x01-0x7f literal length)
+// instructions, per pack-format.txt.
+func applyDelta(base, delta []byte) ([]byte, error) {
+	srcSize, n := readDeltaSize(delta)
+	if n == 0 {
+		return nil, fmt.Errorf("truncated delta (source size)")
+	}
+	delta = delta[n:]
+	if int(srcSize) != len(base) {
+		return nil, fmt.Errorf("delta base size mismatch: want %d, have %d", srcSize, len(base))
+	}
+
+	dstSize, n := readDeltaSize(delta)
+	if n == 0 {
+		return nil, fmt.Errorf("truncated delta (target size)")
+	}
+	delta = delta[n:]
+
+	out := make([]byte, 0, dstSize)
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 != 0 {
+			var cpOff, cpSize uint32
+			if op&0x01 != 0 {
+				cpOff |= uint32(delta[0])
+				delta = delta[1:]
+			}
+			if op&0x02 != 0 {
+				cpOff |= uint32(delta[0]) << 8
+				delta = delta[1:]
+			}
+			if op&0x04 != 0 {
+				cpOff |= uint32(delta[0]) << 16
+				delta = delta[1:]
+			}
+			if op&0x08 != 0 {
+				cpOff |= uint32(delta[0]) << 24
+				delta = delta[1:]
+			}
+			if op&0x10 != 0 {
+				cpSize |= uint32(delta[0])
+				delta = delta[1:]
+			}
+			if op&0x20 != 0 {
+				cpSize |= uint32(delta[0]) << 8
+				delta = delta[1:]
+			}
+			if op&0x40 != 0 {
+				cpSize |= uint32(delta[0]) << 16
+				delta = delta[1:]
+			}
+			if cpSize == 0 {
+				cpSize = 0x10000
+			}
+			if int(cpOff)+int(cpSize) > len(base) {
+				return nil, fmt.Errorf("delta copy out of range")
+			}
+			out = append(out, base[cpOff:cpOff+cpSize]...)
+		} else if op != 0 {
+			size := int(op)
+			if size > len(delta) {
+				return nil, fmt.Errorf("truncated delta (insert)")
+			}
+			out = append(out, delta[:size]...)
+			delta = delta[size:]
+		} else {
+			return nil, fmt.Errorf("invalid delta opcode 0")
+		}
+	}
+
+	if len(out) != int(dstSize) {
+		return nil, fmt.Errorf("delta result size mismatch: want %d, got %d", dstSize, len(out))
+	}
+	return out, nil
+}
+
+// readDeltaSize reads the little-endian, 7-bits-per-byte varint used for the
+// source/target sizes embedded at the start of a delta instruction stream,
+// returning the decoded value and the number of bytes consumed.
+func readDeltaSize(b []byte) (uint64, int) {
+	var size uint64
+	var shift uint
+	for i, c := range b {
+		size |= uint64(c&0x7f) << shift
+		shift += 7
+		if c&0x80 == 0 {
+			return size, i + 1
+		}
+	}
+	return 0, 0
+}
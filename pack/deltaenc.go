@@ -0,0 +1,190 @@
+package pack
+
+// blockSize is the granularity of the rolling-hash index used to find
+// copyable regions of a base object when building a delta.
+const blockSize = 16
+
+// deltaIndex is a chained hash table over fixed-size blocks of a base
+// object, used to find candidate copy sources when diffing a target object
+// against it.
+type deltaIndex struct {
+	base    []byte
+	table   map[uint64]int // hash(block) -> most recent offset
+	chain   []int          // chain[offset] -> previous offset with same hash, or -1
+}
+
+func newDeltaIndex(base []byte) *deltaIndex {
+	idx := &deltaIndex{
+		base:  base,
+		table: make(map[uint64]int),
+	}
+	if len(base) < blockSize {
+		return idx
+	}
+	idx.chain = make([]int, len(base)-blockSize+1)
+	for off := 0; off+blockSize <= len(base); off++ {
+		h := hashBlock(base[off : off+blockSize])
+		if prev, ok := idx.table[h]; ok {
+			idx.chain[off] = prev
+		} else {
+			idx.chain[off] = -1
+		}
+		idx.table[h] = off
+	}
+	return idx
+}
+
+func hashBlock(b []byte) uint64 {
+	var h uint64 = 14695981039346656037 // FNV-1a offset basis
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= 1099511628211
+	}
+	return h
+}
+
+// bestMatch finds the longest run in base that matches target starting at
+// targetOff, by probing the hash chain for the block at that offset and
+// extending each candidate forwards.
+func (idx *deltaIndex) bestMatch(target []byte, targetOff int) (baseOff, length int) {
+	if targetOff+blockSize > len(target) {
+		return 0, 0
+	}
+	h := hashBlock(target[targetOff : targetOff+blockSize])
+
+	bestLen := 0
+	bestOff := 0
+	tries := 0
+	for off, ok := idx.table[h]; ok && tries < 64; off, ok = idx.chainNext(off) {
+		tries++
+		l := matchLen(idx.base, off, target, targetOff)
+		if l > bestLen {
+			bestLen = l
+			bestOff = off
+		}
+	}
+	return bestOff, bestLen
+}
+
+func (idx *deltaIndex) chainNext(off int) (int, bool) {
+	if off < 0 || off >= len(idx.chain) {
+		return 0, false
+	}
+	prev := idx.chain[off]
+	return prev, prev >= 0
+}
+
+func matchLen(base []byte, baseOff int, target []byte, targetOff int) int {
+	n := 0
+	for baseOff+n < len(base) && targetOff+n < len(target) && base[baseOff+n] == target[targetOff+n] {
+		n++
+	}
+	return n
+}
+
+// encodeDelta produces a git delta instruction stream turning base into
+// target: a greedy left-to-right scan that emits a copy instruction for
+// every match found by the rolling-hash index and coalesces the rest into
+// insert instructions.
+func encodeDelta(base, target []byte) []byte {
+	var out []byte
+	out = appendDeltaSize(out, uint64(len(base)))
+	out = appendDeltaSize(out, uint64(len(target)))
+
+	idx := newDeltaIndex(base)
+
+	var insertBuf []byte
+	flushInsert := func() {
+		for len(insertBuf) > 0 {
+			n := len(insertBuf)
+			if n > 0x7f {
+				n = 0x7f
+			}
+			out = append(out, byte(n))
+			out = append(out, insertBuf[:n]...)
+			insertBuf = insertBuf[n:]
+		}
+	}
+
+	i := 0
+	for i < len(target) {
+		baseOff, length := idx.bestMatch(target, i)
+		if length < blockSize {
+			insertBuf = append(insertBuf, target[i])
+			i++
+			continue
+		}
+		// A copy instruction carries at most three size bytes.
+		if length > 0xffffff {
+			length = 0xffffff
+		}
+		flushInsert()
+		out = appendCopy(out, baseOff, length)
+		i += length
+	}
+	flushInsert()
+
+	return out
+}
+
+// appendDeltaSize writes the little-endian, 7-bits-per-byte varint used for
+// the source/target sizes at the start of a delta stream.
+func appendDeltaSize(out []byte, v uint64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+		} else {
+			out = append(out, b)
+			return out
+		}
+	}
+}
+
+// appendCopy emits a copy instruction for base[off:off+size], using the
+// minimal number of offset/size bytes per pack-format.txt.
+func appendCopy(out []byte, off, size int) []byte {
+	op := byte(0x80)
+	var args []byte
+
+	o := uint32(off)
+	if o&0xff != 0 {
+		op |= 0x01
+		args = append(args, byte(o))
+	}
+	if (o>>8)&0xff != 0 {
+		op |= 0x02
+		args = append(args, byte(o>>8))
+	}
+	if (o>>16)&0xff != 0 {
+		op |= 0x04
+		args = append(args, byte(o>>16))
+	}
+	if (o>>24)&0xff != 0 {
+		op |= 0x08
+		args = append(args, byte(o>>24))
+	}
+
+	s := uint32(size)
+	encSize := s
+	if encSize == 0x10000 {
+		encSize = 0
+	}
+	if encSize&0xff != 0 {
+		op |= 0x10
+		args = append(args, byte(encSize))
+	}
+	if (encSize>>8)&0xff != 0 {
+		op |= 0x20
+		args = append(args, byte(encSize>>8))
+	}
+	if (encSize>>16)&0xff != 0 {
+		op |= 0x40
+		args = append(args, byte(encSize>>16))
+	}
+
+	out = append(out, op)
+	out = append(out, args...)
+	return out
+}
@@ -0,0 +1,25 @@
+package pack
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDeltaRoundTrip(t *testing.T) {
+	base := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog\n"), 20)
+	target := append(append([]byte{}, base[:100]...), []byte("A SMALL CHANGE IN THE MIDDLE")...)
+	target = append(target, base[100:]...)
+
+	delta := encodeDelta(base, target)
+
+	out, err := applyDelta(base, delta)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out, target) {
+		t.Fatalf("round trip mismatch: got %d bytes, want %d", len(out), len(target))
+	}
+	if len(delta) >= len(target) {
+		t.Fatalf("delta (%d bytes) did not compress target (%d bytes)", len(delta), len(target))
+	}
+}
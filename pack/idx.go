@@ -0,0 +1,118 @@
+package pack
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+var idxMagic = [4]byte{0xff, 't', 'O', 'c'}
+
+// Index is a parsed v2 .idx file: it lets a caller look up an object's
+// offset within the matching .pack by SHA-1 without scanning the whole pack.
+type Index struct {
+	shas    [][20]byte // sorted ascending, parallel to crcs/offsets
+	crcs    []uint32
+	offsets []uint32
+	large   []uint64 // overflow table for packs bigger than 2GiB
+	trailer [20]byte // SHA-1 of the pack this index belongs to
+}
+
+// ReadIndex parses a v2 packfile index. v1 (undelimited, no magic/version
+// header) is not supported; modern git has written v2 by default for years.
+func ReadIndex(r io.Reader) (*Index, error) {
+	var hdr [8]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("reading idx header: %w", err)
+	}
+	if [4]byte{hdr[0], hdr[1], hdr[2], hdr[3]} != idxMagic {
+		return nil, fmt.Errorf("unsupported idx version (missing v2 magic)")
+	}
+	if version := binary.BigEndian.Uint32(hdr[4:8]); version != 2 {
+		return nil, fmt.Errorf("unsupported idx version %d", version)
+	}
+
+	var fanout [256]uint32
+	if err := binary.Read(r, binary.BigEndian, &fanout); err != nil {
+		return nil, fmt.Errorf("reading fanout table: %w", err)
+	}
+	nobj := int(fanout[255])
+
+	idx := &Index{
+		shas:    make([][20]byte, nobj),
+		crcs:    make([]uint32, nobj),
+		offsets: make([]uint32, nobj),
+	}
+
+	for i := 0; i < nobj; i++ {
+		if _, err := io.ReadFull(r, idx.shas[i][:]); err != nil {
+			return nil, fmt.Errorf("reading sha %d: %w", i, err)
+		}
+	}
+	if err := binary.Read(r, binary.BigEndian, &idx.crcs); err != nil {
+		return nil, fmt.Errorf("reading crc table: %w", err)
+	}
+	if err := binary.Read(r, binary.BigEndian, &idx.offsets); err != nil {
+		return nil, fmt.Errorf("reading offset table: %w", err)
+	}
+
+	nlarge := 0
+	for _, off := range idx.offsets {
+		if off&0x80000000 != 0 {
+			nlarge++
+		}
+	}
+	if nlarge > 0 {
+		idx.large = make([]uint64, nlarge)
+		if err := binary.Read(r, binary.BigEndian, &idx.large); err != nil {
+			return nil, fmt.Errorf("reading large offset table: %w", err)
+		}
+	}
+
+	// The trailer is <pack SHA-1><idx SHA-1>, in that order; PackSHA1 hands
+	// back the first one, so it's what idx.trailer stores.
+	if _, err := io.ReadFull(r, idx.trailer[:]); err != nil {
+		return nil, fmt.Errorf("reading pack checksum: %w", err)
+	}
+	var idxSum [20]byte
+	if _, err := io.ReadFull(r, idxSum[:]); err != nil {
+		return nil, fmt.Errorf("reading idx checksum: %w", err)
+	}
+
+	return idx, nil
+}
+
+// PackSHA1 returns the SHA-1 of the packfile this index was built from.
+func (idx *Index) PackSHA1() [20]byte { return idx.trailer }
+
+// Len returns the number of objects the index covers.
+func (idx *Index) Len() int { return len(idx.shas) }
+
+// FindOffset returns the byte offset of sha within the matching packfile.
+func (idx *Index) FindOffset(sha [20]byte) (int64, bool) {
+	i := sort.Search(len(idx.shas), func(i int) bool {
+		return bytesCompare(idx.shas[i][:], sha[:]) >= 0
+	})
+	if i >= len(idx.shas) || idx.shas[i] != sha {
+		return 0, false
+	}
+
+	off := idx.offsets[i]
+	if off&0x80000000 == 0 {
+		return int64(off), true
+	}
+	return int64(idx.large[off&0x7fffffff]), true
+}
+
+func bytesCompare(a, b []byte) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+package pack
+
+import (
+	"bytes"
+	"testing"
+)
+
+// TestIndexRoundTrip writes a v2 .idx with WriteIndex and reads it back with
+// ReadIndex, checking FindOffset resolves every entry (including one past
+// the 2GiB large-offset threshold) and that PackSHA1 returns the pack's
+// checksum, not the idx file's own trailing checksum - the two sit back to
+// back in the trailer and are easy to swap.
+func TestIndexRoundTrip(t *testing.T) {
+	mkSHA := func(b byte) [20]byte {
+		var sha [20]byte
+		for i := range sha {
+			sha[i] = b
+		}
+		return sha
+	}
+
+	entries := []IndexEntry{
+		{SHA: mkSHA(0x01), CRC32: 0x11111111, Offset: 12},
+		{SHA: mkSHA(0x02), CRC32: 0x22222222, Offset: 4096},
+		{SHA: mkSHA(0x03), CRC32: 0x33333333, Offset: 0x1_8000_0000}, // > 0x7fffffff, needs the large-offset table
+	}
+	packSum := mkSHA(0xaa)
+
+	var buf bytes.Buffer
+	if err := WriteIndex(&buf, entries, packSum); err != nil {
+		t.Fatal(err)
+	}
+
+	idx, err := ReadIndex(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := idx.PackSHA1(); got != packSum {
+		t.Fatalf("PackSHA1() = %x, want %x (the pack's checksum, not the idx file's own)", got, packSum)
+	}
+	if idx.Len() != len(entries) {
+		t.Fatalf("Len() = %d, want %d", idx.Len(), len(entries))
+	}
+
+	for _, e := range entries {
+		off, ok := idx.FindOffset(e.SHA)
+		if !ok {
+			t.Fatalf("FindOffset(%x): not found", e.SHA)
+		}
+		if off != e.Offset {
+			t.Fatalf("FindOffset(%x) = %d, want %d", e.SHA, off, e.Offset)
+		}
+	}
+
+	if _, ok := idx.FindOffset(mkSHA(0xff)); ok {
+		t.Fatal("FindOffset of an absent SHA-1 should report not found")
+	}
+}
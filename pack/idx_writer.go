@@ -0,0 +1,89 @@
+package pack
+
+import (
+	"crypto/sha1"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+// IndexEntry is one row of a v2 .idx: an object's identity, its CRC32 over
+// the compressed pack entry, and its offset within the pack.
+type IndexEntry struct {
+	SHA    [20]byte
+	CRC32  uint32
+	Offset int64
+}
+
+// WriteIndex writes a v2 .idx file for entries against the pack whose
+// trailer SHA-1 is packSum, matching the format WritePack's output expects
+// (fanout table, sorted SHA-1s, CRC32s, offsets, large-offset overflow,
+// pack checksum, then a trailing SHA-1 of everything written so far).
+func WriteIndex(w io.Writer, entries []IndexEntry, packSum [20]byte) error {
+	sorted := make([]IndexEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytesCompare(sorted[i].SHA[:], sorted[j].SHA[:]) < 0
+	})
+
+	var buf []byte
+	buf = append(buf, idxMagic[:]...)
+	buf = append(buf, 0, 0, 0, 2)
+
+	var fanout [256]uint32
+	for _, e := range sorted {
+		fanout[e.SHA[0]]++
+	}
+	for i := 1; i < 256; i++ {
+		fanout[i] += fanout[i-1]
+	}
+	for _, f := range fanout {
+		buf = appendUint32(buf, f)
+	}
+
+	for _, e := range sorted {
+		buf = append(buf, e.SHA[:]...)
+	}
+	for _, e := range sorted {
+		buf = appendUint32(buf, e.CRC32)
+	}
+
+	var large []uint64
+	for _, e := range sorted {
+		if e.Offset > 0x7fffffff {
+			buf = appendUint32(buf, 0x80000000|uint32(len(large)))
+			large = append(large, uint64(e.Offset))
+		} else {
+			buf = appendUint32(buf, uint32(e.Offset))
+		}
+	}
+	for _, off := range large {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], off)
+		buf = append(buf, b[:]...)
+	}
+
+	buf = append(buf, packSum[:]...)
+
+	if _, err := w.Write(buf); err != nil {
+		return err
+	}
+
+	sum := sha1.Sum(buf)
+	_, err := w.Write(sum[:])
+	return err
+}
+
+func appendUint32(buf []byte, v uint32) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], v)
+	return append(buf, b[:]...)
+}
+
+// CRC32ForEntry computes the CRC32 git stores per entry in the .idx: the
+// checksum of the compressed bytes as they appear in the packfile (header
+// plus zlib stream), not the decompressed object.
+func CRC32ForEntry(compressedEntry []byte) uint32 {
+	return crc32.ChecksumIEEE(compressedEntry)
+}
@@ -0,0 +1,377 @@
+// Package pack implements streaming access to git packfiles (.pack) and
+// their delta-compressed entries, producing the same ipld.Node types that
+// ipldgit.ParseCompressedObject returns for loose objects.
+package pack
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	git "github.com/ipfs/go-ipld-git"
+	"github.com/ipld/go-ipld-prime"
+)
+
+// ObjType is the 3-bit type tag stored in a packfile entry header.
+type ObjType int
+
+const (
+	ObjCommit   ObjType = 1
+	ObjTree     ObjType = 2
+	ObjBlob     ObjType = 3
+	ObjTag      ObjType = 4
+	ObjOfsDelta ObjType = 6
+	ObjRefDelta ObjType = 7
+)
+
+var packMagic = [4]byte{'P', 'A', 'C', 'K'}
+
+// Entry is a single decoded object from a packfile: either a base object
+// (commit/tree/blob/tag) or a delta that has been resolved against its base.
+type Entry struct {
+	Offset int64
+	Type   ObjType
+	Data   []byte
+}
+
+// rawEntry is what a single pass over the packfile produces before delta
+// resolution: either a resolved base object, or the raw delta instruction
+// stream plus enough information to find its base once available.
+type rawEntry struct {
+	offset  int64
+	typ     ObjType
+	data    []byte // base bytes, or the delta instruction stream
+	baseOff int64  // valid when typ == ObjOfsDelta
+	baseRef [20]byte
+	hasRef  bool // valid when typ == ObjRefDelta
+}
+
+// Pack is a scanner over a single .pack file. It resolves OBJ_OFS_DELTA and
+// OBJ_REF_DELTA entries against bases it has already seen, and falls back to
+// Thin for bases that live outside the pack (thin packs).
+type Pack struct {
+	r    io.ReaderAt
+	size int64
+	nobj uint32
+
+	// bases maps a pack offset to the fully-resolved bytes of the object
+	// stored there, so later deltas can resolve against it without a
+	// second pass over the file.
+	bases map[int64]*Entry
+	byRef map[[20]byte]*Entry
+
+	// Thin, if set, resolves a base object by SHA-1 when it is not
+	// present in this pack (thin pack support).
+	Thin func(sha [20]byte) ([]byte, ObjType, error)
+}
+
+// NewScanner reads and validates the packfile header at the start of r and
+// returns a Pack ready to iterate with ForEachObject.
+func NewScanner(r io.ReaderAt, size int64) (*Pack, error) {
+	var hdr [12]byte
+	if _, err := r.ReadAt(hdr[:], 0); err != nil {
+		return nil, fmt.Errorf("reading pack header: %w", err)
+	}
+	if [4]byte{hdr[0], hdr[1], hdr[2], hdr[3]} != packMagic {
+		return nil, fmt.Errorf("not a packfile (bad magic)")
+	}
+	version := binary.BigEndian.Uint32(hdr[4:8])
+	if version != 2 && version != 3 {
+		return nil, fmt.Errorf("unsupported pack version %d", version)
+	}
+	nobj := binary.BigEndian.Uint32(hdr[8:12])
+
+	return &Pack{
+		r:     r,
+		size:  size,
+		nobj:  nobj,
+		bases: make(map[int64]*Entry, nobj),
+		byRef: make(map[[20]byte]*Entry, nobj),
+	}, nil
+}
+
+// NumObjects returns the object count recorded in the pack header.
+func (p *Pack) NumObjects() int { return int(p.nobj) }
+
+// ForEachObject walks every entry in the pack, resolving deltas against
+// their bases, and calls fn with the corresponding ipld.Node in storage
+// order. OBJ_OFS_DELTA bases are always earlier in the file and resolve
+// immediately; OBJ_REF_DELTA may name a base that hasn't been scanned yet
+// (or lives outside the pack for a thin pack), so those are resolved in a
+// second pass once every entry's bytes are known.
+func (p *Pack) ForEachObject(fn func(ipld.Node) error) error {
+	raws, err := p.scan()
+	if err != nil {
+		return err
+	}
+
+	resolved := make([]*Entry, len(raws))
+	for i, re := range raws {
+		if re.typ == ObjOfsDelta || re.typ == ObjRefDelta {
+			continue
+		}
+		e := &Entry{Offset: re.offset, Type: re.typ, Data: re.data}
+		resolved[i] = e
+		p.bases[re.offset] = e
+		p.byRef[gitSHA1(e.Type, e.Data)] = e
+	}
+
+	remaining := len(raws)
+	for _, e := range resolved {
+		if e != nil {
+			remaining--
+		}
+	}
+
+	for remaining > 0 {
+		progressed := false
+		for i, re := range raws {
+			if resolved[i] != nil {
+				continue
+			}
+
+			var base *Entry
+			switch re.typ {
+			case ObjOfsDelta:
+				base = p.bases[re.baseOff]
+			case ObjRefDelta:
+				base = p.byRef[re.baseRef]
+				if base == nil && p.Thin != nil {
+					data, typ, err := p.Thin(re.baseRef)
+					if err != nil {
+						return fmt.Errorf("entry %d thin base %x: %w", i, re.baseRef, err)
+					}
+					base = &Entry{Type: typ, Data: data}
+				}
+			}
+			if base == nil {
+				continue
+			}
+
+			data, err := applyDelta(base.Data, re.data)
+			if err != nil {
+				return fmt.Errorf("entry %d apply delta: %w", i, err)
+			}
+			e := &Entry{Offset: re.offset, Type: base.Type, Data: data}
+			resolved[i] = e
+			p.bases[re.offset] = e
+			p.byRef[gitSHA1(e.Type, e.Data)] = e
+			remaining--
+			progressed = true
+		}
+		if !progressed {
+			return fmt.Errorf("thin pack: %d entries could not be resolved", remaining)
+		}
+	}
+
+	for _, e := range resolved {
+		nd, err := toNode(e.Type, e.Data)
+		if err != nil {
+			return err
+		}
+		if err := fn(nd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scan makes a single sequential pass over the packfile, inflating every
+// entry's payload but leaving delta resolution for ForEachObject.
+func (p *Pack) scan() ([]rawEntry, error) {
+	sr := io.NewSectionReader(p.r, 0, p.size)
+	if _, err := sr.Seek(12, io.SeekStart); err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(sr)
+
+	out := make([]rawEntry, p.nobj)
+	offset := int64(12)
+
+	for i := uint32(0); i < p.nobj; i++ {
+		start := offset
+		typ, size, n, err := readEntryHeader(br)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d header: %w", i, err)
+		}
+		offset += int64(n)
+
+		re := rawEntry{offset: start, typ: typ}
+
+		switch typ {
+		case ObjOfsDelta:
+			back, n2, err := readOfsDeltaBase(br)
+			if err != nil {
+				return nil, fmt.Errorf("entry %d ofs-delta base: %w", i, err)
+			}
+			offset += int64(n2)
+			re.baseOff = start - back
+
+		case ObjRefDelta:
+			if _, err := io.ReadFull(br, re.baseRef[:]); err != nil {
+				return nil, fmt.Errorf("entry %d ref-delta base: %w", i, err)
+			}
+			offset += 20
+			re.hasRef = true
+		}
+
+		payload, n3, err := inflate(br, size)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d inflate: %w", i, err)
+		}
+		offset += n3
+		re.data = payload
+
+		out[i] = re
+	}
+
+	return out, nil
+}
+
+// readEntryHeader decodes the variable-length type+size header used for
+// every packfile entry: 3 type bits and 4 size bits in the first byte,
+// followed by 7 size bits per continuation byte (little-endian order, most
+// significant bits come from later bytes).
+func readEntryHeader(r io.ByteReader) (ObjType, uint64, int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	n := 1
+	typ := ObjType((b >> 4) & 0x7)
+	size := uint64(b & 0xf)
+	shift := uint(4)
+	for b&0x80 != 0 {
+		b, err = r.ReadByte()
+		if err != nil {
+			return 0, 0, 0, err
+		}
+		n++
+		size |= uint64(b&0x7f) << shift
+		shift += 7
+	}
+	return typ, size, n, nil
+}
+
+// readOfsDeltaBase decodes the negative varint offset back to the delta's
+// base, per the OBJ_OFS_DELTA encoding in pack-format.txt.
+func readOfsDeltaBase(r io.ByteReader) (int64, int, error) {
+	b, err := r.ReadByte()
+	if err != nil {
+		return 0, 0, err
+	}
+	n := 1
+	val := int64(b & 0x7f)
+	for b&0x80 != 0 {
+		b, err = r.ReadByte()
+		if err != nil {
+			return 0, 0, err
+		}
+		n++
+		val = ((val + 1) << 7) | int64(b&0x7f)
+	}
+	return val, n, nil
+}
+
+// inflate zlib-decompresses exactly one object payload of the given
+// (uncompressed) size from r, returning the raw bytes and the number of
+// compressed bytes consumed.
+func inflate(r *bufio.Reader, size uint64) ([]byte, int64, error) {
+	cr := &countingReader{r: r}
+	zr, err := zlib.NewReader(cr)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer zr.Close()
+
+	buf := make([]byte, size)
+	if _, err := io.ReadFull(zr, buf); err != nil {
+		return nil, 0, err
+	}
+	// Drain the zlib checksum/trailer so the counting reader reflects the
+	// true number of compressed bytes consumed for this entry, and so a
+	// failed Adler-32 check on a corrupt entry is actually reported instead
+	// of silently accepted.
+	if _, err := io.Copy(io.Discard, zr); err != nil {
+		return nil, 0, err
+	}
+	return buf, cr.n, nil
+}
+
+// countingReader tracks how many compressed bytes a per-entry zlib.Reader
+// has consumed from the pack's shared bufio.Reader. It implements ReadByte,
+// not just Read: without it, compress/flate wraps a plain io.Reader in its
+// own bufio.Reader and reads ahead in larger chunks than the zlib stream
+// actually needs, stranding those look-ahead bytes inside the per-entry
+// reader (discarded on Close) instead of leaving them in br for the next
+// entry. Satisfying io.ByteReader keeps flate reading byte-exact from br, so
+// any entry after the first isn't skipped past.
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+// toNode builds the ipld.Node for a fully-resolved object by re-framing it
+// as a loose object ("<type> <size>\x00<data>", zlib-compressed) and handing
+// it to the same ParseCompressedObject the rest of the module uses, so pack
+// and loose objects always produce identical nodes.
+func toNode(typ ObjType, data []byte) (ipld.Node, error) {
+	kind := typeName(typ)
+	if kind == "" {
+		return nil, fmt.Errorf("unexpected object type %d", typ)
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	fmt.Fprintf(zw, "%s %d\x00", kind, len(data))
+	zw.Write(data)
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return git.ParseCompressedObject(&compressed)
+}
+
+// gitSHA1 is the object hash git itself would compute, used to key byRef so
+// later OBJ_REF_DELTA entries can resolve against objects already scanned.
+func gitSHA1(typ ObjType, data []byte) [20]byte {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s %d\x00", typeName(typ), len(data))
+	h.Write(data)
+	var out [20]byte
+	copy(out[:], h.Sum(nil))
+	return out
+}
+
+func typeName(typ ObjType) string {
+	switch typ {
+	case ObjCommit:
+		return "commit"
+	case ObjTree:
+		return "tree"
+	case ObjBlob:
+		return "blob"
+	case ObjTag:
+		return "tag"
+	default:
+		return ""
+	}
+}
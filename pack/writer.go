@@ -0,0 +1,337 @@
+package pack
+
+import (
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"sort"
+)
+
+// windowSize bounds how many same-type neighbors on each side of an object,
+// in the write order, are considered as delta bases for it, trading
+// compression ratio for time spent searching.
+const windowSize = 10
+
+// maxDeltaRatio is the largest a delta may be, relative to the object it
+// replaces, before it's rejected in favor of storing the object whole.
+const maxDeltaRatio = 0.9
+
+// maxDeltaChainDepth is the chain-depth limit bestBase applies to a delta
+// base candidate, the same value as git's own default pack.depth. The depth
+// counted is that of the candidate's base chain as decided so far: a chain
+// that runs through a REF_DELTA base decided later is not counted, so a
+// chain in the written pack can be longer than this.
+const maxDeltaChainDepth = 50
+
+// Object is a single git object to be written into a packfile.
+type Object struct {
+	SHA  [20]byte
+	Type ObjType
+	Data []byte
+}
+
+// WritePack writes objs as a v2 packfile to w and returns the pack's SHA-1
+// trailer plus, for each written object, the offset it was written at (in
+// the same order as objs, for building a matching .idx).
+//
+// Objects are grouped by type and, within a type, each one is matched
+// against a window of up to windowSize same-type neighbors on either side of
+// it in that grouping (not just ones already written) - REF_DELTA is used
+// when the chosen base hasn't been written yet (it names the base by SHA-1,
+// which doesn't require it to come first), and the cheaper OFS_DELTA is used
+// once the base has already been written. baseOf records each object's
+// chosen base as it's decided, so bestBase can refuse a candidate whose own
+// base chain already loops back to the object being written - otherwise two
+// mutually-similar objects can each pick the other as their base, and
+// ForEachObject can never resolve either one.
+func WritePack(w io.Writer, objs []Object) ([20]byte, []int64, error) {
+	order := deltaOrder(objs)
+	windows := deltaWindows(objs, order)
+
+	cw := &checksumWriter{w: w, h: sha1.New()}
+
+	var hdr [12]byte
+	copy(hdr[0:4], packMagic[:])
+	binary.BigEndian.PutUint32(hdr[4:8], 2)
+	binary.BigEndian.PutUint32(hdr[8:12], uint32(len(objs)))
+	if _, err := cw.Write(hdr[:]); err != nil {
+		return [20]byte{}, nil, err
+	}
+
+	offsets := make([]int64, len(objs))
+	written := make(map[[20]byte]int64, len(objs))
+	baseOf := make(map[int]int, len(objs))
+	offset := int64(12)
+
+	for _, i := range order {
+		obj := objs[i]
+		baseIdx, baseOff, payload, ok := bestBase(objs, windows[i], baseOf, written, i)
+
+		var n int64
+		var err error
+		switch {
+		case ok && baseOff >= 0:
+			n, err = writeOfsDeltaEntry(cw, offset-baseOff, payload)
+		case ok:
+			n, err = writeRefDeltaEntry(cw, objs[baseIdx].SHA, payload)
+		default:
+			n, err = writeBaseEntry(cw, obj.Type, obj.Data)
+		}
+		if err != nil {
+			return [20]byte{}, nil, fmt.Errorf("writing object %x: %w", obj.SHA, err)
+		}
+
+		if ok {
+			baseOf[i] = baseIdx
+		} else {
+			baseOf[i] = -1
+		}
+
+		offsets[i] = offset
+		written[obj.SHA] = offset
+		offset += n
+	}
+
+	sum := cw.h.Sum(nil)
+	if _, err := w.Write(sum); err != nil {
+		return [20]byte{}, nil, err
+	}
+
+	var trailer [20]byte
+	copy(trailer[:], sum)
+	return trailer, offsets, nil
+}
+
+// deltaOrder returns an object ordering that groups objects by type so
+// deltaWindows only ever pairs up same-type candidates.
+func deltaOrder(objs []Object) []int {
+	order := make([]int, len(objs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return objs[order[a]].Type < objs[order[b]].Type
+	})
+	return order
+}
+
+// deltaWindows returns, for each object's index into objs, the indices of
+// the up-to-windowSize same-type objects on either side of it within the
+// write order - candidates may come before or after it, so the base
+// WritePack picks for a given object is not guaranteed to already be
+// written by the time it's needed, the same way a real packer can choose a
+// REF_DELTA base that appears later in the file.
+func deltaWindows(objs []Object, order []int) map[int][]int {
+	groups := make(map[ObjType][]int)
+	for _, i := range order {
+		t := objs[i].Type
+		groups[t] = append(groups[t], i)
+	}
+
+	out := make(map[int][]int, len(objs))
+	for _, group := range groups {
+		for pos, i := range group {
+			lo := pos - windowSize
+			if lo < 0 {
+				lo = 0
+			}
+			hi := pos + windowSize + 1
+			if hi > len(group) {
+				hi = len(group)
+			}
+
+			var win []int
+			for _, j := range group[lo:hi] {
+				if j == i {
+					continue
+				}
+				win = append(win, j)
+			}
+			out[i] = win
+		}
+	}
+	return out
+}
+
+// bestBase finds a same-type candidate in window usable as a delta base for
+// objs[i]: trying smallest-first (a reasonable proxy for "most similar"
+// without a full diff against every candidate), it skips any candidate
+// whose base chain (as recorded in baseOf) already loops back to i - taking
+// it would make the candidate's bytes depend, transitively, on i's own
+// delta being resolved first - and any candidate already at
+// maxDeltaChainDepth. It also skips a candidate whose resulting delta
+// doesn't beat maxDeltaRatio, falling through to the next one instead of
+// giving up outright. Returns the chosen base's index and payload, plus its
+// pack offset if already written (enabling OFS_DELTA) or -1 otherwise
+// (REF_DELTA, naming the base by SHA-1 since its offset isn't known yet).
+func bestBase(objs []Object, window []int, baseOf map[int]int, written map[[20]byte]int64, i int) (baseIdx int, baseOff int64, payload []byte, ok bool) {
+	obj := objs[i]
+
+	candidates := append([]int(nil), window...)
+	sort.SliceStable(candidates, func(a, b int) bool {
+		return len(objs[candidates[a]].Data) < len(objs[candidates[b]].Data)
+	})
+
+	for _, j := range candidates {
+		if objs[j].SHA == obj.SHA {
+			continue
+		}
+		if chainDepth(baseOf, j) >= maxDeltaChainDepth {
+			continue
+		}
+		if chainsTo(baseOf, j, i) {
+			continue
+		}
+
+		delta := encodeDelta(objs[j].Data, obj.Data)
+		if len(delta) >= int(float64(len(obj.Data))*maxDeltaRatio) {
+			continue
+		}
+
+		if off, have := written[objs[j].SHA]; have {
+			return j, off, delta, true
+		}
+		return j, -1, delta, true
+	}
+	return -1, -1, nil, false
+}
+
+// chainDepth counts how many already-decided bases lie between i and the
+// whole object its chain eventually bottoms out at.
+func chainDepth(baseOf map[int]int, i int) int {
+	depth := 0
+	for {
+		b, ok := baseOf[i]
+		if !ok || b < 0 {
+			return depth
+		}
+		depth++
+		i = b
+	}
+}
+
+// chainsTo reports whether from's already-decided base chain eventually
+// reaches target, i.e. whether from's bytes already depend, directly or
+// transitively, on target's. Used to refuse a base whose chain loops back
+// to the object being written.
+func chainsTo(baseOf map[int]int, from, target int) bool {
+	for {
+		if from == target {
+			return true
+		}
+		b, ok := baseOf[from]
+		if !ok || b < 0 {
+			return false
+		}
+		from = b
+	}
+}
+
+func writeBaseEntry(cw *checksumWriter, typ ObjType, data []byte) (int64, error) {
+	start := cw.n
+	if err := writeEntryHeader(cw, typ, uint64(len(data))); err != nil {
+		return 0, err
+	}
+	if err := deflate(cw, data); err != nil {
+		return 0, err
+	}
+	return cw.n - start, nil
+}
+
+func writeOfsDeltaEntry(cw *checksumWriter, back int64, payload []byte) (int64, error) {
+	start := cw.n
+	if err := writeEntryHeader(cw, ObjOfsDelta, uint64(len(payload))); err != nil {
+		return 0, err
+	}
+	if err := writeOfsDeltaBase(cw, back); err != nil {
+		return 0, err
+	}
+	if err := deflate(cw, payload); err != nil {
+		return 0, err
+	}
+	return cw.n - start, nil
+}
+
+func writeRefDeltaEntry(cw *checksumWriter, base [20]byte, payload []byte) (int64, error) {
+	start := cw.n
+	if err := writeEntryHeader(cw, ObjRefDelta, uint64(len(payload))); err != nil {
+		return 0, err
+	}
+	if _, err := cw.Write(base[:]); err != nil {
+		return 0, err
+	}
+	if err := deflate(cw, payload); err != nil {
+		return 0, err
+	}
+	return cw.n - start, nil
+}
+
+// writeEntryHeader writes the 3-bit-type/variable-length-size header shared
+// by every packfile entry, the inverse of readEntryHeader.
+func writeEntryHeader(w io.Writer, typ ObjType, size uint64) error {
+	b := byte(typ)<<4 | byte(size&0xf)
+	size >>= 4
+	for size != 0 {
+		if _, err := w.Write([]byte{b | 0x80}); err != nil {
+			return err
+		}
+		b = byte(size & 0x7f)
+		size >>= 7
+	}
+	_, err := w.Write([]byte{b})
+	return err
+}
+
+// writeOfsDeltaBase writes the negative varint offset-to-base encoding used
+// by OBJ_OFS_DELTA entries, the inverse of readOfsDeltaBase.
+func writeOfsDeltaBase(w io.Writer, back int64) error {
+	// Encode from the least significant 7 bits up, matching the decoder's
+	// "((val+1)<<7)|bits" accumulation, then emit most-significant-byte
+	// first with continuation bits set on every byte but the last.
+	var bytesOut []byte
+	v := back
+	bytesOut = append(bytesOut, byte(v&0x7f))
+	v >>= 7
+	for v != 0 {
+		v--
+		bytesOut = append(bytesOut, byte(v&0x7f))
+		v >>= 7
+	}
+	for i := len(bytesOut) - 1; i >= 0; i-- {
+		b := bytesOut[i]
+		if i != 0 {
+			b |= 0x80
+		}
+		if _, err := w.Write([]byte{b}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deflate(w io.Writer, data []byte) error {
+	zw := zlib.NewWriter(w)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// checksumWriter wraps an io.Writer, feeding every byte written through a
+// running SHA-1 (for the pack trailer) while tracking the total byte count
+// (so entries can report their own length for the .idx offset table).
+type checksumWriter struct {
+	w io.Writer
+	h hash.Hash
+	n int64
+}
+
+func (c *checksumWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.h.Write(p[:n])
+	c.n += int64(n)
+	return n, err
+}
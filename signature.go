@@ -0,0 +1,230 @@
+package ipldgit
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/ssh"
+)
+
+// SignatureFormat identifies which of the two signature encodings git
+// understands a Signature carries.
+type SignatureFormat int
+
+const (
+	// SignatureUnknown marks a Signature that could not be classified.
+	SignatureUnknown SignatureFormat = iota
+	SignaturePGP
+	SignatureSSH
+)
+
+// sshNamespace is the SSHSIG namespace git signs commits and tags under;
+// see gpg.ssh.allowedSignersFile / Documentation/technical/signature-format.txt.
+const sshNamespace = "git"
+
+// Signature is a parsed detached signature pulled off a Commit's gpgsig
+// header or a Tag's trailing signature block, still in its original armored
+// form so it can be re-verified or re-exported unchanged.
+type Signature struct {
+	Format SignatureFormat
+	Data   []byte
+}
+
+func classifySignature(data []byte) SignatureFormat {
+	switch {
+	case bytes.Contains(data, []byte("BEGIN SSH SIGNATURE")):
+		return SignatureSSH
+	case bytes.Contains(data, []byte("BEGIN PGP SIGNATURE")):
+		return SignaturePGP
+	default:
+		return SignatureUnknown
+	}
+}
+
+// Signature returns the commit's gpgsig header, if any, along with the
+// canonical bytes that were signed (the encoded commit with the gpgsig
+// header removed but every other header left in its original order).
+func (c Commit) Signature() (*Signature, []byte, error) {
+	raw, err := c.canonicalBytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, sig, ok := extractHeaderSignature(raw, "gpgsig")
+	if !ok {
+		return nil, nil, nil
+	}
+	return &Signature{Format: classifySignature(sig), Data: sig}, payload, nil
+}
+
+// Verify checks the commit's gpgsig header against keyring and returns the
+// signing entity on success. Commits signed with an SSH key are checked
+// against allowed, a set of authorized SSH public keys (there being no
+// equivalent of a PGP keyring for SSH signatures).
+func (c Commit) Verify(keyring openpgp.KeyRing, allowed []ssh.PublicKey) (*openpgp.Entity, error) {
+	sig, payload, err := c.Signature()
+	if err != nil {
+		return nil, err
+	}
+	if sig == nil {
+		return nil, fmt.Errorf("commit has no gpgsig header")
+	}
+	return verifyDetached(*sig, payload, keyring, allowed)
+}
+
+// Signature returns the tag's trailing PGP/SSH signature block, if any,
+// along with the canonical bytes that were signed (everything in the tag
+// object up to the start of the signature block).
+func (t Tag) Signature() (*Signature, []byte, error) {
+	raw, err := t.canonicalBytes()
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, sig, ok := extractTrailingSignature(raw)
+	if !ok {
+		return nil, nil, nil
+	}
+	return &Signature{Format: classifySignature(sig), Data: sig}, payload, nil
+}
+
+// Verify checks the tag's trailing signature against keyring/allowed, same
+// as Commit.Verify.
+func (t Tag) Verify(keyring openpgp.KeyRing, allowed []ssh.PublicKey) (*openpgp.Entity, error) {
+	sig, payload, err := t.Signature()
+	if err != nil {
+		return nil, err
+	}
+	if sig == nil {
+		return nil, fmt.Errorf("tag has no signature")
+	}
+	return verifyDetached(*sig, payload, keyring, allowed)
+}
+
+func verifyDetached(sig Signature, payload []byte, keyring openpgp.KeyRing, allowed []ssh.PublicKey) (*openpgp.Entity, error) {
+	switch sig.Format {
+	case SignaturePGP:
+		return openpgp.CheckArmoredDetachedSignature(keyring, bytes.NewReader(payload), bytes.NewReader(sig.Data), nil)
+	case SignatureSSH:
+		return nil, verifySSHSignature(sig.Data, payload, allowed)
+	default:
+		return nil, fmt.Errorf("unrecognized signature format")
+	}
+}
+
+// canonicalBytes re-serializes the commit via the module's loose-object
+// encoder, which is the single source of truth for git's canonical byte
+// layout; re-deriving it here (rather than hand-formatting headers) is what
+// keeps the signed payload byte-exact with what git itself hashed.
+func (c Commit) canonicalBytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Encoder(c, &buf); err != nil {
+		return nil, err
+	}
+	return stripObjectFraming(buf.Bytes())
+}
+
+func (t Tag) canonicalBytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := Encoder(t, &buf); err != nil {
+		return nil, err
+	}
+	return stripObjectFraming(buf.Bytes())
+}
+
+// stripObjectFraming removes the "<type> <size>\x00" header Encoder writes
+// ahead of the object body, since that framing is never part of what git
+// signs.
+func stripObjectFraming(raw []byte) ([]byte, error) {
+	i := bytes.IndexByte(raw, 0)
+	if i < 0 {
+		return nil, fmt.Errorf("malformed object: no null byte after header")
+	}
+	return raw[i+1:], nil
+}
+
+// extractHeaderSignature pulls a multi-line git commit header (continuation
+// lines begin with a single space, per the convention gpgsig uses for
+// armored text) out of raw, returning the object with that header removed
+// (but every other header in its original order and the blank-line/message
+// split untouched) plus the designated header's de-indented value.
+func extractHeaderSignature(raw []byte, name string) (payload, value []byte, ok bool) {
+	lines := splitKeepNewline(raw)
+	prefix := []byte(name + " ")
+
+	start := -1
+	end := -1
+	for i, line := range lines {
+		if line == "\n" || line == "" {
+			break // headers end at the first blank line
+		}
+		if start < 0 {
+			if bytes.HasPrefix([]byte(line), prefix) {
+				start = i
+				end = i + 1
+				continue
+			}
+			continue
+		}
+		if strings.HasPrefix(line, " ") {
+			end = i + 1
+			continue
+		}
+		break
+	}
+	if start < 0 {
+		return raw, nil, false
+	}
+
+	var val bytes.Buffer
+	val.WriteString(strings.TrimPrefix(lines[start], name+" "))
+	for _, line := range lines[start+1 : end] {
+		val.WriteString(strings.TrimPrefix(line, " "))
+	}
+
+	var out bytes.Buffer
+	for i, line := range lines {
+		if i >= start && i < end {
+			continue
+		}
+		out.WriteString(line)
+	}
+
+	return out.Bytes(), bytes.TrimRight(val.Bytes(), "\n"), true
+}
+
+// extractTrailingSignature splits a tag object's bytes at the start of a
+// trailing "-----BEGIN {PGP,SSH} SIGNATURE-----" armor block, which git
+// appends directly after the tag message with no header wrapper.
+func extractTrailingSignature(raw []byte) (payload, sig []byte, ok bool) {
+	markers := []string{"-----BEGIN PGP SIGNATURE-----", "-----BEGIN SSH SIGNATURE-----"}
+	idx := -1
+	for _, m := range markers {
+		if i := bytes.Index(raw, []byte(m)); i >= 0 && (idx < 0 || i < idx) {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return raw, nil, false
+	}
+	// Everything up to the marker, including the newline terminating the
+	// tag message, is part of the signed payload; the signature block
+	// starts on the line right after it.
+	return raw[:idx], raw[idx:], true
+}
+
+func splitKeepNewline(raw []byte) []string {
+	var lines []string
+	r := bufio.NewReader(bytes.NewReader(raw))
+	for {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			lines = append(lines, line)
+		}
+		if err != nil {
+			break
+		}
+	}
+	return lines
+}
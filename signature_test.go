@@ -0,0 +1,45 @@
+package ipldgit
+
+import "testing"
+
+func TestExtractHeaderSignature(t *testing.T) {
+	raw := []byte("tree abc\n" +
+		"parent def\n" +
+		"gpgsig -----BEGIN PGP SIGNATURE-----\n" +
+		" \n" +
+		" iQEzBAAB\n" +
+		" =AAAA\n" +
+		" -----END PGP SIGNATURE-----\n" +
+		"author Someone <some@one.somewhere> 123456 +0000\n" +
+		"\n" +
+		"commit message\n")
+
+	payload, sig, ok := extractHeaderSignature(raw, "gpgsig")
+	if !ok {
+		t.Fatal("expected to find gpgsig header")
+	}
+	if string(payload) != "tree abc\nparent def\nauthor Someone <some@one.somewhere> 123456 +0000\n\ncommit message\n" {
+		t.Fatalf("unexpected payload: %q", payload)
+	}
+	if classifySignature(sig) != SignaturePGP {
+		t.Fatalf("expected PGP signature, got format %d", classifySignature(sig))
+	}
+}
+
+func TestExtractTrailingSignature(t *testing.T) {
+	raw := []byte("object abc\ntype commit\ntag v1.0\ntagger Someone <some@one.somewhere> 123456 +0000\n\nrelease notes\n" +
+		"-----BEGIN PGP SIGNATURE-----\n" +
+		"iQEzBAAB\n" +
+		"-----END PGP SIGNATURE-----\n")
+
+	payload, sig, ok := extractTrailingSignature(raw)
+	if !ok {
+		t.Fatal("expected to find trailing signature")
+	}
+	if string(payload) != "object abc\ntype commit\ntag v1.0\ntagger Someone <some@one.somewhere> 123456 +0000\n\nrelease notes\n" {
+		t.Fatalf("unexpected payload: %q", payload)
+	}
+	if classifySignature(sig) != SignaturePGP {
+		t.Fatalf("expected PGP signature, got format %d", classifySignature(sig))
+	}
+}
@@ -0,0 +1,196 @@
+package ipldgit
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+// DefaultChunkSize is the size of each raw-leaf chunk ChunkAndParse splits
+// a blob's content into.
+const DefaultChunkSize = 1 << 20 // 1MiB
+
+// rawLinkPrototype is the link prototype used for the individual chunks a
+// large blob is split into; each chunk is content-addressed on its own so
+// identical chunks across different blobs are only ever stored once.
+var rawLinkPrototype = cidlink.LinkPrototype{
+	Prefix: cid.Prefix{
+		Version:  1,
+		Codec:    cid.Raw,
+		MhType:   sha1MultihashCode,
+		MhLength: 20,
+	},
+}
+
+// sha1MultihashCode is the multicodec identifier for SHA-1, matching the
+// multihash git itself already uses for object identity.
+const sha1MultihashCode = 0x11
+
+// ParseCompressedObjectReader parses a zlib-compressed loose object from r
+// the same way ParseCompressedObject does. It reads the object's header to
+// find its declared size, then tees the original compressed bytes into a
+// buffer as they're decompressed and hands that buffer to
+// ParseCompressedObject once the stream is fully read. The object is
+// therefore decompressed twice: once here, discarding the body as it is
+// read, and again inside ParseCompressedObject. The only extra copy in
+// memory is of the (typically much smaller) compressed bytes themselves,
+// never a second full copy of the decompressed body. This is the building block ChunkAndParse
+// uses to also get a look at a large blob's content as it's read.
+func ParseCompressedObjectReader(r io.Reader) (ipld.Node, error) {
+	var compressed bytes.Buffer
+	zr, err := zlib.NewReader(io.TeeReader(r, &compressed))
+	if err != nil {
+		return nil, fmt.Errorf("opening zlib stream: %w", err)
+	}
+
+	kind, _, err := readLooseObjectHeader(zr)
+	if err != nil {
+		zr.Close()
+		return nil, err
+	}
+	if _, err := io.Copy(io.Discard, zr); err != nil {
+		zr.Close()
+		return nil, fmt.Errorf("reading %s body: %w", kind, err)
+	}
+	if err := zr.Close(); err != nil {
+		return nil, err
+	}
+
+	return ParseCompressedObject(&compressed)
+}
+
+// ChunkAndParse parses a loose blob object from r exactly like
+// ParseCompressedObjectReader - reading the stream through once here and
+// handing the original compressed bytes to ParseCompressedObject, which
+// decompresses them a second time, rather than buffering the full
+// decompressed body and re-compressing it - additionally
+// splitting its content into DefaultChunkSize-sized pieces and storing each
+// one as its own raw block through ls as it's read, so a caller processing
+// many large blobs isn't holding more than one chunk's worth of extra copy
+// in flight at a time for that part of the pipeline. It returns the usual
+// Blob node - whose git SHA-1 is unaffected by this chunking, satisfying
+// the same equality check TestObjectParse runs, since it's built from the
+// same compressed bytes the object arrived in - alongside the CIDs of the
+// stored chunks, in order, for content-addressed reuse across blobs that
+// share data.
+//
+// Only "blob" objects are chunked; commits, trees, and tags are routed
+// through the same path unchanged since they're never
+// large enough for chunking to matter.
+func ChunkAndParse(ctx context.Context, r io.Reader, ls ipld.LinkSystem, chunkSize int) (ipld.Node, []cid.Cid, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	var compressed bytes.Buffer
+	zr, err := zlib.NewReader(io.TeeReader(r, &compressed))
+	if err != nil {
+		return nil, nil, fmt.Errorf("opening zlib stream: %w", err)
+	}
+
+	kind, size, err := readLooseObjectHeader(zr)
+	if err != nil {
+		zr.Close()
+		return nil, nil, err
+	}
+
+	var chunks []cid.Cid
+	if kind != "blob" {
+		if _, err := io.Copy(io.Discard, zr); err != nil {
+			zr.Close()
+			return nil, nil, fmt.Errorf("reading %s body: %w", kind, err)
+		}
+	} else {
+		chunk := make([]byte, chunkSize)
+		for off := 0; off < size; off += chunkSize {
+			n := chunkSize
+			if off+n > size {
+				n = size - off
+			}
+			if _, err := io.ReadFull(zr, chunk[:n]); err != nil {
+				zr.Close()
+				return nil, nil, fmt.Errorf("reading blob chunk at %d: %w", off, err)
+			}
+
+			lnk, err := ls.Store(ipld.LinkContext{Ctx: ctx}, rawLinkPrototype, basicBytesNode(append([]byte{}, chunk[:n]...)))
+			if err != nil {
+				zr.Close()
+				return nil, nil, fmt.Errorf("storing chunk at %d: %w", off, err)
+			}
+			cl, ok := lnk.(cidlink.Link)
+			if !ok {
+				zr.Close()
+				return nil, nil, fmt.Errorf("unexpected link implementation from LinkSystem.Store")
+			}
+			chunks = append(chunks, cl.Cid)
+		}
+	}
+	if err := zr.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	nd, err := ParseCompressedObject(&compressed)
+	return nd, chunks, err
+}
+
+// readLooseObjectHeader reads the "<type> <size>\x00" header every loose
+// git object starts with, after zlib decompression.
+func readLooseObjectHeader(r io.Reader) (kind string, size int, err error) {
+	var header bytes.Buffer
+	buf := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return "", 0, fmt.Errorf("reading object header: %w", err)
+		}
+		if buf[0] == 0 {
+			break
+		}
+		header.WriteByte(buf[0])
+		if header.Len() > 64 {
+			return "", 0, fmt.Errorf("object header too long (missing null byte?)")
+		}
+	}
+
+	if _, err := fmt.Sscanf(header.String(), "%s %d", &kind, &size); err != nil {
+		return "", 0, fmt.Errorf("malformed object header %q: %w", header.String(), err)
+	}
+	return kind, size, nil
+}
+
+// basicBytesNode wraps a raw chunk of bytes as the minimal ipld.Node
+// needed to store it through a LinkSystem as a raw-leaf block.
+type basicBytesNode []byte
+
+func (n basicBytesNode) Kind() ipld.Kind { return ipld.Kind_Bytes }
+func (basicBytesNode) LookupByString(string) (ipld.Node, error) {
+	return nil, fmt.Errorf("bytes node has no fields")
+}
+func (basicBytesNode) LookupByNode(ipld.Node) (ipld.Node, error) {
+	return nil, fmt.Errorf("bytes node has no fields")
+}
+func (basicBytesNode) LookupByIndex(int64) (ipld.Node, error) {
+	return nil, fmt.Errorf("not a list")
+}
+func (basicBytesNode) LookupBySegment(ipld.PathSegment) (ipld.Node, error) {
+	return nil, fmt.Errorf("bytes node has no fields")
+}
+func (basicBytesNode) MapIterator() ipld.MapIterator   { return nil }
+func (basicBytesNode) ListIterator() ipld.ListIterator { return nil }
+func (basicBytesNode) Length() int64                   { return -1 }
+func (basicBytesNode) IsAbsent() bool                  { return false }
+func (basicBytesNode) IsNull() bool                    { return false }
+func (basicBytesNode) AsBool() (bool, error)            { return false, fmt.Errorf("not a bool") }
+func (basicBytesNode) AsInt() (int64, error)            { return 0, fmt.Errorf("not an int") }
+func (basicBytesNode) AsFloat() (float64, error)        { return 0, fmt.Errorf("not a float") }
+func (basicBytesNode) AsString() (string, error)        { return "", fmt.Errorf("not a string") }
+func (n basicBytesNode) AsBytes() ([]byte, error)       { return n, nil }
+func (basicBytesNode) AsLink() (ipld.Link, error)       { return nil, fmt.Errorf("not a link") }
+func (basicBytesNode) Prototype() ipld.NodePrototype    { return nil }
+
+var _ ipld.Node = basicBytesNode(nil)
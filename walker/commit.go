@@ -0,0 +1,143 @@
+package walker
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	git "github.com/ipfs/go-ipld-git"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+// commitParents returns the CIDs nd's "parents" field points at, in order.
+func commitParents(nd git.Commit) ([]cid.Cid, error) {
+	parents, err := nd.LookupByString("parents")
+	if err != nil {
+		return nil, fmt.Errorf("looking up parents: %w", err)
+	}
+
+	it := parents.ListIterator()
+	if it == nil {
+		return nil, nil
+	}
+
+	var out []cid.Cid
+	for !it.Done() {
+		_, v, err := it.Next()
+		if err != nil {
+			return nil, err
+		}
+		lnk, err := v.AsLink()
+		if err != nil {
+			return nil, err
+		}
+		cl, ok := lnk.(cidlink.Link)
+		if !ok {
+			return nil, fmt.Errorf("unexpected link implementation for parent")
+		}
+		out = append(out, cl.Cid)
+	}
+	return out, nil
+}
+
+// commitDate parses nd's committer date, the field `git log`'s default
+// ordering sorts on.
+func commitDate(nd git.Commit) (time.Time, error) {
+	committer, err := nd.LookupByString("committer")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("looking up committer: %w", err)
+	}
+
+	dateNode, err := committer.LookupByString("Date")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("looking up committer date: %w", err)
+	}
+	dateStr, err := dateNode.AsString()
+	if err != nil {
+		return time.Time{}, err
+	}
+	sec, err := strconv.ParseInt(dateStr, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid committer date %q: %w", dateStr, err)
+	}
+
+	tzNode, err := committer.LookupByString("Timezone")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("looking up committer timezone: %w", err)
+	}
+	tzStr, err := tzNode.AsString()
+	if err != nil {
+		return time.Time{}, err
+	}
+	loc, err := parseGitTimezone(tzStr)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(sec, 0).In(loc), nil
+}
+
+// parseGitTimezone turns git's "+HHMM"/"-HHMM" offset into a *time.Location.
+func parseGitTimezone(tz string) (*time.Location, error) {
+	if len(tz) != 5 || (tz[0] != '+' && tz[0] != '-') {
+		return nil, fmt.Errorf("invalid git timezone %q", tz)
+	}
+	hh, err := strconv.Atoi(tz[1:3])
+	if err != nil {
+		return nil, fmt.Errorf("invalid git timezone %q: %w", tz, err)
+	}
+	mm, err := strconv.Atoi(tz[3:5])
+	if err != nil {
+		return nil, fmt.Errorf("invalid git timezone %q: %w", tz, err)
+	}
+	offset := hh*3600 + mm*60
+	if tz[0] == '-' {
+		offset = -offset
+	}
+	return time.FixedZone(tz, offset), nil
+}
+
+// resolveTreePath walks tree through path's "/"-separated components and
+// returns the hash of the final component, or a zero CID if any component
+// is missing.
+func resolveTreePath(ctx context.Context, ls ipld.LinkSystem, tree ipld.Link, path string) (cid.Cid, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	current := tree
+	for i, seg := range segments {
+		nd, err := ls.Load(ipld.LinkContext{Ctx: ctx}, current, git.Type.Tree)
+		if err != nil {
+			return cid.Undef, fmt.Errorf("loading tree for %q: %w", path, err)
+		}
+
+		entry, err := nd.LookupByString(seg)
+		if err != nil {
+			// Path doesn't exist at this commit; that's not an error for
+			// the caller, just "nothing to compare".
+			return cid.Undef, nil
+		}
+
+		hashNode, err := entry.LookupByString("Hash")
+		if err != nil {
+			return cid.Undef, fmt.Errorf("looking up hash for %q: %w", seg, err)
+		}
+		lnk, err := hashNode.AsLink()
+		if err != nil {
+			return cid.Undef, err
+		}
+		cl, ok := lnk.(cidlink.Link)
+		if !ok {
+			return cid.Undef, fmt.Errorf("unexpected link implementation for %q", seg)
+		}
+
+		if i == len(segments)-1 {
+			return cl.Cid, nil
+		}
+		current = lnk
+	}
+	return cid.Undef, nil
+}
@@ -0,0 +1,28 @@
+package walker
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseGitTimezone(t *testing.T) {
+	loc, err := parseGitTimezone("+0130")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, off := time.Unix(0, 0).In(loc).Zone(); off != 90*60 {
+		t.Fatalf("expected 90m offset, got %ds", off)
+	}
+
+	loc, err = parseGitTimezone("-0500")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, off := time.Unix(0, 0).In(loc).Zone(); off != -5*60*60 {
+		t.Fatalf("expected -5h offset, got %ds", off)
+	}
+
+	if _, err := parseGitTimezone("bogus"); err == nil {
+		t.Fatal("expected error for malformed timezone")
+	}
+}
@@ -0,0 +1,38 @@
+package walker
+
+import (
+	"time"
+
+	"github.com/ipfs/go-cid"
+)
+
+// heapItem is a single entry in commitHeap: a commit CID ordered by its
+// committer date (when known; the seed commit NewCommitIter pushes has a
+// zero date and would sort after every dated item, but it is pushed alone
+// onto an empty heap and so comes out first).
+type heapItem struct {
+	id   cid.Cid
+	when time.Time
+}
+
+// commitHeap is a max-heap on committer date, giving OrderDate traversal
+// newest-first, the same order `git log` prints commits in.
+type commitHeap []heapItem
+
+func (h commitHeap) Len() int { return len(h) }
+func (h commitHeap) Less(i, j int) bool {
+	return h[i].when.After(h[j].when)
+}
+func (h commitHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *commitHeap) Push(x interface{}) {
+	*h = append(*h, x.(heapItem))
+}
+
+func (h *commitHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
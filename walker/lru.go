@@ -0,0 +1,61 @@
+package walker
+
+import (
+	"container/list"
+
+	"github.com/ipfs/go-cid"
+	git "github.com/ipfs/go-ipld-git"
+)
+
+// commitCache is a bounded least-recently-used cache of decoded commits,
+// keeping large-history walks from re-fetching the same ancestor through
+// the LinkSystem every time a second branch of a merge reaches it.
+type commitCache struct {
+	capacity int
+	ll       *list.List
+	items    map[cid.Cid]*list.Element
+}
+
+type cacheEntry struct {
+	key   cid.Cid
+	value git.Commit
+}
+
+func newCommitCache(capacity int) *commitCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &commitCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[cid.Cid]*list.Element, capacity),
+	}
+}
+
+func (c *commitCache) get(key cid.Cid) (git.Commit, bool) {
+	el, ok := c.items[key]
+	if !ok {
+		return git.Commit{}, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).value, true
+}
+
+func (c *commitCache) put(key cid.Cid, value git.Commit) {
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheEntry).value = value
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&cacheEntry{key: key, value: value})
+	c.items[key] = el
+
+	if c.ll.Len() > c.capacity {
+		oldest := c.ll.Back()
+		if oldest != nil {
+			c.ll.Remove(oldest)
+			delete(c.items, oldest.Value.(*cacheEntry).key)
+		}
+	}
+}
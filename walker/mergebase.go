@@ -0,0 +1,85 @@
+package walker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ipfs/go-cid"
+	git "github.com/ipfs/go-ipld-git"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+// color tracks, for the two-colored BFS in MergeBase, which of the two
+// starting commits' ancestry a given commit has been reached from.
+type color int
+
+const (
+	colorNone color = 0
+	colorA    color = 1
+	colorB    color = 2
+)
+
+// MergeBase returns a common ancestor of a and b by expanding both
+// histories breadth-first in lockstep and returning the first commit
+// reached from both sides. When one side reaches a common ancestor only by
+// a longer path, the commit returned can be an ancestor of another common
+// ancestor, and so differ from what `git merge-base` reports.
+func MergeBase(ctx context.Context, ls ipld.LinkSystem, a, b cid.Cid) (cid.Cid, error) {
+	colors := map[cid.Cid]color{a: colorA, b: colorB}
+	queue := []cid.Cid{a, b}
+	cache := newCommitCache(4096)
+
+	load := func(id cid.Cid) (git.Commit, error) {
+		if nd, ok := cache.get(id); ok {
+			return nd, nil
+		}
+		nd, err := ls.Load(ipld.LinkContext{Ctx: ctx}, cidlink.Link{Cid: id}, git.Type.Commit)
+		if err != nil {
+			return git.Commit{}, err
+		}
+		commit, ok := nd.(git.Commit)
+		if !ok {
+			return git.Commit{}, fmt.Errorf("%s did not resolve to a commit", id)
+		}
+		cache.put(id, commit)
+		return commit, nil
+	}
+
+	if a == b {
+		return a, nil
+	}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if colors[id] == (colorA | colorB) {
+			return id, nil
+		}
+
+		commit, err := load(id)
+		if err != nil {
+			return cid.Undef, err
+		}
+		parents, err := commitParents(commit)
+		if err != nil {
+			return cid.Undef, err
+		}
+
+		for _, p := range parents {
+			before := colors[p]
+			after := before | colors[id]
+			if after == before {
+				continue // already fully colored, nothing new to propagate
+			}
+			colors[p] = after
+			if after == (colorA | colorB) {
+				return p, nil
+			}
+			queue = append(queue, p)
+		}
+	}
+
+	return cid.Undef, nil
+}
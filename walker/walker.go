@@ -0,0 +1,268 @@
+// Package walker implements traversal over IPLD-git commit DAGs: the
+// equivalents of `git log`, `git log -- path`, and `git log --first-parent`.
+package walker
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	git "github.com/ipfs/go-ipld-git"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+)
+
+// Order selects the traversal strategy used by a CommitIter.
+type Order int
+
+const (
+	// OrderBFS visits commits breadth-first from head.
+	OrderBFS Order = iota
+	// OrderDFS visits commits depth-first from head.
+	OrderDFS
+	// OrderDate visits commits in committer-date order, newest first,
+	// matching plain `git log`.
+	OrderDate
+)
+
+// CommitIter yields commits reachable from a starting point, nearest to
+// farthest in the chosen Order, honoring any filters configured via Option.
+type CommitIter interface {
+	// Next returns the next commit's CID and decoded node, or false once
+	// the traversal is exhausted.
+	Next(ctx context.Context) (cid.Cid, git.Commit, bool, error)
+}
+
+// config holds the options accumulated from a NewCommitIter call.
+type config struct {
+	order       Order
+	firstParent bool
+	since       time.Time
+	until       time.Time
+	path        string
+	cacheSize   int
+}
+
+// Option configures a CommitIter; see WithOrder, FirstParent, Since, Until,
+// and Path.
+type Option func(*config)
+
+// WithOrder selects BFS, DFS, or committer-date ordering. The default is
+// OrderDate, matching `git log`.
+func WithOrder(o Order) Option { return func(c *config) { c.order = o } }
+
+// FirstParent restricts traversal to each commit's first parent only,
+// matching `git log --first-parent`.
+func FirstParent() Option { return func(c *config) { c.firstParent = true } }
+
+// Since only yields commits committed at or after t (the committer date,
+// the same field OrderDate sorts on - not the author date, which can differ
+// for rebased or amended commits).
+func Since(t time.Time) Option { return func(c *config) { c.since = t } }
+
+// Until only yields commits committed at or before t. See Since.
+func Until(t time.Time) Option { return func(c *config) { c.until = t } }
+
+// Path restricts traversal to commits that changed the tree entry at path,
+// matching `git log -- path`. A commit is included when the resolved tree
+// entry hash at path differs from the same entry in every parent (the
+// classic history-simplification rule).
+func Path(path string) Option { return func(c *config) { c.path = path } }
+
+// CacheSize bounds the number of decoded commits kept in the walker's LRU,
+// trading memory for fewer redundant loads through the LinkSystem on large
+// histories with wide merge fan-in. The default is 4096.
+func CacheSize(n int) Option { return func(c *config) { c.cacheSize = n } }
+
+// NewCommitIter returns a CommitIter starting at head.
+func NewCommitIter(ls ipld.LinkSystem, head cid.Cid, opts ...Option) CommitIter {
+	cfg := config{order: OrderDate, cacheSize: 4096}
+	for _, o := range opts {
+		o(&cfg)
+	}
+
+	w := &walk{
+		ls:    ls,
+		cfg:   cfg,
+		cache: newCommitCache(cfg.cacheSize),
+	}
+
+	switch cfg.order {
+	case OrderDate:
+		w.pq = &commitHeap{}
+		w.pushSeed(head)
+	default:
+		w.stack = []cid.Cid{head}
+	}
+	w.seen = map[cid.Cid]bool{}
+
+	return w
+}
+
+// walk is the shared CommitIter implementation for all three orderings; BFS
+// and DFS share a slice used as a queue or stack respectively, while
+// OrderDate uses a min-heap (inverted to behave as a max-heap on date).
+type walk struct {
+	ls    ipld.LinkSystem
+	cfg   config
+	cache *commitCache
+	seen  map[cid.Cid]bool
+
+	stack []cid.Cid  // BFS (as a queue) or DFS (as a stack)
+	pq    *commitHeap // OrderDate
+}
+
+func (w *walk) pushSeed(c cid.Cid) {
+	heap.Push(w.pq, heapItem{id: c})
+}
+
+func (w *walk) Next(ctx context.Context) (cid.Cid, git.Commit, bool, error) {
+	for {
+		id, ok := w.pop()
+		if !ok {
+			return cid.Undef, git.Commit{}, false, nil
+		}
+		if w.seen[id] {
+			continue
+		}
+		w.seen[id] = true
+
+		nd, err := w.load(ctx, id)
+		if err != nil {
+			return cid.Undef, git.Commit{}, false, err
+		}
+
+		include, err := w.filter(ctx, id, nd)
+		if err != nil {
+			return cid.Undef, git.Commit{}, false, err
+		}
+
+		parents, err := commitParents(nd)
+		if err != nil {
+			return cid.Undef, git.Commit{}, false, err
+		}
+		if w.cfg.firstParent && len(parents) > 1 {
+			parents = parents[:1]
+		}
+		for _, p := range parents {
+			w.push(p)
+		}
+
+		if include {
+			return id, nd, true, nil
+		}
+	}
+}
+
+func (w *walk) push(id cid.Cid) {
+	switch w.cfg.order {
+	case OrderDate:
+		when, _ := w.commitDate(id)
+		heap.Push(w.pq, heapItem{id: id, when: when})
+	default:
+		w.stack = append(w.stack, id)
+	}
+}
+
+func (w *walk) pop() (cid.Cid, bool) {
+	switch w.cfg.order {
+	case OrderDate:
+		if w.pq.Len() == 0 {
+			return cid.Undef, false
+		}
+		return heap.Pop(w.pq).(heapItem).id, true
+	case OrderBFS:
+		if len(w.stack) == 0 {
+			return cid.Undef, false
+		}
+		id := w.stack[0]
+		w.stack = w.stack[1:]
+		return id, true
+	default: // OrderDFS
+		if len(w.stack) == 0 {
+			return cid.Undef, false
+		}
+		id := w.stack[len(w.stack)-1]
+		w.stack = w.stack[:len(w.stack)-1]
+		return id, true
+	}
+}
+
+func (w *walk) load(ctx context.Context, id cid.Cid) (git.Commit, error) {
+	if nd, ok := w.cache.get(id); ok {
+		return nd, nil
+	}
+	nd, err := w.ls.Load(ipld.LinkContext{Ctx: ctx}, cidlink.Link{Cid: id}, git.Type.Commit)
+	if err != nil {
+		return git.Commit{}, fmt.Errorf("loading commit %s: %w", id, err)
+	}
+	commit, ok := nd.(git.Commit)
+	if !ok {
+		return git.Commit{}, fmt.Errorf("%s did not resolve to a commit", id)
+	}
+	w.cache.put(id, commit)
+	return commit, nil
+}
+
+func (w *walk) commitDate(id cid.Cid) (time.Time, error) {
+	nd, err := w.load(context.Background(), id)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return commitDate(nd)
+}
+
+// filter applies Since/Until/Path, loading parent trees only when a Path
+// filter is configured (the common case, plain `git log`, stays a pure
+// graph walk with no extra tree reads).
+func (w *walk) filter(ctx context.Context, id cid.Cid, nd git.Commit) (bool, error) {
+	when, err := commitDate(nd)
+	if err != nil {
+		return false, err
+	}
+	if !w.cfg.since.IsZero() && when.Before(w.cfg.since) {
+		return false, nil
+	}
+	if !w.cfg.until.IsZero() && when.After(w.cfg.until) {
+		return false, nil
+	}
+	if w.cfg.path == "" {
+		return true, nil
+	}
+	return w.pathChanged(ctx, nd)
+}
+
+// pathChanged resolves the tree entry at w.cfg.path in nd and in each
+// parent, returning true if it differs from every parent (or nd has no
+// parents, i.e. it introduced the path).
+func (w *walk) pathChanged(ctx context.Context, nd git.Commit) (bool, error) {
+	here, err := resolveTreePath(ctx, w.ls, nd.GitTree, w.cfg.path)
+	if err != nil {
+		return false, err
+	}
+
+	parents, err := commitParents(nd)
+	if err != nil {
+		return false, err
+	}
+	if len(parents) == 0 {
+		return true, nil
+	}
+
+	for _, p := range parents {
+		pc, err := w.load(ctx, p)
+		if err != nil {
+			return false, err
+		}
+		there, err := resolveTreePath(ctx, w.ls, pc.GitTree, w.cfg.path)
+		if err != nil {
+			return false, err
+		}
+		if there == here {
+			return false, nil
+		}
+	}
+	return true, nil
+}
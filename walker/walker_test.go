@@ -0,0 +1,183 @@
+package walker
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"crypto/sha1"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	git "github.com/ipfs/go-ipld-git"
+	"github.com/ipld/go-ipld-prime"
+	cidlink "github.com/ipld/go-ipld-prime/linking/cid"
+	mh "github.com/multiformats/go-multihash"
+)
+
+// fixtureRepo is a tiny in-memory git history, built by hand-assembling
+// loose commit objects (bypassing Encoder/the LinkSystem entirely, the same
+// way git_test.go's fixtures are plain files on disk) and serving them back
+// through a map-backed ipld.LinkSystem, so CommitIter and MergeBase can be
+// exercised without a real .git directory.
+type fixtureRepo struct {
+	store map[cid.Cid][]byte
+}
+
+func newFixtureRepo() *fixtureRepo {
+	return &fixtureRepo{store: make(map[cid.Cid][]byte)}
+}
+
+// addCommit hashes and stores a synthetic commit object with the given
+// parents, committer time, and message, returning its CID.
+func (f *fixtureRepo) addCommit(parents []cid.Cid, when int64, message string) cid.Cid {
+	var parentLines strings.Builder
+	for _, p := range parents {
+		dec, err := mh.Decode(p.Hash())
+		if err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(&parentLines, "parent %x\n", dec.Digest)
+	}
+
+	person := fmt.Sprintf("Test Author <test@example.com> %d +0000", when)
+	body := []byte(fmt.Sprintf(
+		"tree 0000000000000000000000000000000000000000\n%sauthor %s\ncommitter %s\n\n%s\n",
+		parentLines.String(), person, person, message,
+	))
+
+	header := fmt.Sprintf("commit %d\x00", len(body))
+	full := append([]byte(header), body...)
+	sha := sha1.Sum(full)
+
+	digest, err := mh.Encode(sha[:], mh.SHA1)
+	if err != nil {
+		panic(err)
+	}
+	id := cid.NewCidV1(cid.GitRaw, digest)
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(full)
+	zw.Close()
+	f.store[id] = compressed.Bytes()
+
+	return id
+}
+
+func (f *fixtureRepo) linkSystem() ipld.LinkSystem {
+	return ipld.LinkSystem{
+		StorageReadOpener: func(_ ipld.LinkContext, lnk ipld.Link) (io.Reader, error) {
+			cl, ok := lnk.(cidlink.Link)
+			if !ok {
+				return nil, fmt.Errorf("unexpected link implementation %T", lnk)
+			}
+			data, ok := f.store[cl.Cid]
+			if !ok {
+				return nil, fmt.Errorf("no such object: %s", cl.Cid)
+			}
+			return bytes.NewReader(data), nil
+		},
+		DecoderChooser: func(ipld.LinkPrototype) (ipld.Decoder, error) {
+			return func(na ipld.NodeAssembler, r io.Reader) error {
+				nd, err := git.ParseCompressedObject(r)
+				if err != nil {
+					return err
+				}
+				return na.AssignNode(nd)
+			}, nil
+		},
+	}
+}
+
+// linearHistory builds root -> mid -> tip, one commit per second starting
+// at unix time 1000, and returns their CIDs oldest first.
+func linearHistory(f *fixtureRepo) (root, mid, tip cid.Cid) {
+	root = f.addCommit(nil, 1000, "root")
+	mid = f.addCommit([]cid.Cid{root}, 2000, "mid")
+	tip = f.addCommit([]cid.Cid{mid}, 3000, "tip")
+	return root, mid, tip
+}
+
+func drain(t *testing.T, it CommitIter) []cid.Cid {
+	t.Helper()
+	var got []cid.Cid
+	for {
+		id, _, ok, err := it.Next(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !ok {
+			return got
+		}
+		got = append(got, id)
+	}
+}
+
+func TestCommitIterDateOrder(t *testing.T) {
+	f := newFixtureRepo()
+	root, mid, tip := linearHistory(f)
+
+	it := NewCommitIter(f.linkSystem(), tip, WithOrder(OrderDate))
+	got := drain(t, it)
+	want := []cid.Cid{tip, mid, root}
+	if len(got) != len(want) {
+		t.Fatalf("got %d commits, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("commit %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+}
+
+func TestCommitIterSinceUntil(t *testing.T) {
+	f := newFixtureRepo()
+	root, mid, tip := linearHistory(f)
+
+	it := NewCommitIter(f.linkSystem(), tip, WithOrder(OrderDate), Since(time.Unix(1500, 0)))
+	got := drain(t, it)
+	want := []cid.Cid{tip, mid}
+	if len(got) != len(want) {
+		t.Fatalf("Since: got %d commits, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("Since commit %d: got %s, want %s", i, got[i], want[i])
+		}
+	}
+	for _, id := range got {
+		if id == root {
+			t.Fatal("Since(1500) should have excluded root (committed at 1000)")
+		}
+	}
+
+	it = NewCommitIter(f.linkSystem(), tip, WithOrder(OrderDate), Until(time.Unix(1500, 0)))
+	got = drain(t, it)
+	if len(got) != 1 || got[0] != root {
+		t.Fatalf("Until: got %v, want [%s]", got, root)
+	}
+}
+
+func TestMergeBase(t *testing.T) {
+	f := newFixtureRepo()
+	root, mid, tip := linearHistory(f)
+	branch := f.addCommit([]cid.Cid{mid}, 2500, "branch")
+
+	base, err := MergeBase(context.Background(), f.linkSystem(), tip, branch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base != mid {
+		t.Fatalf("got merge base %s, want mid %s", base, mid)
+	}
+
+	if base, err = MergeBase(context.Background(), f.linkSystem(), tip, root); err != nil {
+		t.Fatal(err)
+	} else if base != root {
+		t.Fatalf("got merge base %s, want root %s", base, root)
+	}
+}